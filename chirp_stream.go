@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/stream"
+	"github.com/lib/pq"
+)
+
+const (
+	ChirpEventCreated = "chirp.created"
+	ChirpEventDeleted = "chirp.deleted"
+
+	// chirpEventsChannel is the Postgres NOTIFY channel every instance
+	// listens on, so a chirp created on one reaches streams on all of them.
+	// New events are announced on it; once sequenced, they are announced
+	// again on chirpEventsSequencedChannel.
+	chirpEventsChannel          = "chirp_events"
+	chirpEventsSequencedChannel = "chirp_events_sequenced"
+
+	chirpStreamBufferSize    = 64
+	chirpStreamHeartbeat     = 15 * time.Second
+	chirpStreamReplayLimit   = 500
+	chirpEventRetention      = 24 * time.Hour
+	chirpEventPruneInterval  = time.Hour
+	chirpEventCatchUpTimeout = 10 * time.Second
+
+	eventListenerPing         = 90 * time.Second
+	eventListenerMinReconnect = time.Second
+	eventListenerMaxReconnect = time.Minute
+)
+
+// chirpEventRef is the part of a chirp event's payload that says which chirp
+// it is about. chirp.deleted events carry only this.
+type chirpEventRef struct {
+	ID      int32  `json:"id"`
+	QuoteOf *int32 `json:"quote_of,omitempty"`
+}
+
+// recordChirpEvent stores a stream event using qtx. Listeners are notified
+// when the surrounding transaction commits, so rolled back changes are never
+// streamed.
+func recordChirpEvent(ctx context.Context, qtx *database.Queries, eventType string, dbChirp database.Chirp) error {
+	var payload any = chirpFromDB(dbChirp)
+	if eventType == ChirpEventDeleted {
+		payload = chirpEventRef{ID: dbChirp.ID, QuoteOf: chirpFromDB(dbChirp).QuoteOf}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return qtx.CreateChirpEvent(ctx, database.CreateChirpEventParams{
+		Type:    eventType,
+		ChirpID: dbChirp.ID,
+		UserID:  dbChirp.UserID,
+		Payload: data,
+	})
+}
+
+func streamEventFromDB(dbEvent database.ChirpEvent) stream.Event {
+	return stream.Event{
+		ID:     dbEvent.Seq.Int64,
+		Type:   dbEvent.Type,
+		UserID: dbEvent.UserID,
+		Data:   dbEvent.Payload,
+	}
+}
+
+// sequenceChirpEvents gives committed events their place in the stream.
+// Sequencers take turns under a lock, so seqs become visible in increasing
+// order and a reader that has seen seq n has seen everything before it.
+func (cfg *apiConfig) sequenceChirpEvents(ctx context.Context) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if err := qtx.LockChirpEventSequencer(ctx); err != nil {
+		return err
+	}
+	n, err := qtx.SequenceChirpEvents(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		if err := qtx.NotifyChirpEventsSequenced(ctx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// runEventListener LISTENs for new chirp events and notifications. New
+// chirp events are sequenced, and sequenced ones published to
+// cfg.chirpStream in seq order; notifications go to cfg.notificationStream.
+// After a dropped connection it catches up on the chirp events committed in
+// the meantime, since NOTIFYs sent while disconnected are lost; missed
+// notifications stay in the inbox.
+func (cfg *apiConfig) runEventListener(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, eventListenerMinReconnect, eventListenerMaxReconnect, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Event listener: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	for _, channel := range []string{chirpEventsChannel, chirpEventsSequencedChannel, notificationsChannel} {
+		if err := listener.Listen(channel); err != nil {
+			log.Printf("Error listening on %s: %s", channel, err)
+			return
+		}
+	}
+
+	lastSeq, err := cfg.db.GetLatestChirpEventSeq(ctx)
+	if err != nil {
+		log.Printf("Error loading latest chirp event: %s", err)
+	}
+	// events recorded while no instance was listening
+	sequence := func() {
+		if err := cfg.sequenceChirpEvents(ctx); err != nil {
+			log.Printf("Error sequencing chirp events: %s", err)
+		}
+	}
+	sequence()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			switch {
+			// a nil notification means the connection was re-established
+			case n == nil:
+				sequence()
+				lastSeq = cfg.catchUpChirpEvents(ctx, lastSeq)
+			case n.Channel == notificationsChannel:
+				cfg.publishNotification(ctx, n.Extra)
+			case n.Channel == chirpEventsChannel:
+				sequence()
+			case n.Channel == chirpEventsSequencedChannel:
+				lastSeq = cfg.catchUpChirpEvents(ctx, lastSeq)
+			}
+		case <-time.After(eventListenerPing):
+			go listener.Ping()
+			// picks up events a failed sequencing run left behind
+			sequence()
+		}
+	}
+}
+
+// catchUpChirpEvents publishes every event after lastSeq and returns the seq
+// of the last one published.
+func (cfg *apiConfig) catchUpChirpEvents(ctx context.Context, lastSeq int64) int64 {
+	ctx, cancel := context.WithTimeout(ctx, chirpEventCatchUpTimeout)
+	defer cancel()
+
+	for {
+		dbEvents, err := cfg.db.GetChirpEventsAfter(ctx, database.GetChirpEventsAfterParams{
+			Seq:   lastSeq,
+			Limit: chirpStreamReplayLimit,
+		})
+		if err != nil {
+			log.Printf("Error catching up on chirp events: %s", err)
+			return lastSeq
+		}
+		for _, dbEvent := range dbEvents {
+			cfg.chirpStream.Publish(streamEventFromDB(dbEvent))
+			lastSeq = dbEvent.Seq.Int64
+		}
+		if len(dbEvents) < chirpStreamReplayLimit {
+			return lastSeq
+		}
+	}
+}
+
+// runChirpEventPrune periodically deletes stream events older than the
+// retention window. Clients resuming from a pruned event miss what was
+// between.
+func (cfg *apiConfig) runChirpEventPrune(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := cfg.db.PruneChirpEvents(ctx, int32(chirpEventRetention.Seconds()))
+		if err != nil {
+			log.Printf("Error pruning chirp events: %s", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d chirp events", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
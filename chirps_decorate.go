@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// decorateChirps fills in the parts of each chirp that live outside the chirps
+// table: rechirp counts, polls, media, links, the embedded quoted chirp and, for
+// authenticated viewers, whether they bookmarked it. Quoted chirps the viewer can no longer
+// see are left out and keep only their QuoteOf id.
+func (cfg *apiConfig) decorateChirps(ctx context.Context, viewer uuid.NullUUID, chirps []Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	quotedIDs := []int32{}
+	for _, chirp := range chirps {
+		if chirp.QuoteOf != nil {
+			quotedIDs = append(quotedIDs, *chirp.QuoteOf)
+		}
+	}
+
+	quoted := map[int32]*Chirp{}
+	if len(quotedIDs) > 0 {
+		dbQuoted, err := cfg.db.GetQuotedChirps(ctx, database.GetQuotedChirpsParams{
+			Ids:      quotedIDs,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return err
+		}
+		for _, dbChirp := range dbQuoted {
+			chirp := chirpFromDB(dbChirp)
+			quoted[chirp.ID] = &chirp
+		}
+	}
+
+	ids := make([]int32, 0, len(chirps)+len(quoted))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+	for id := range quoted {
+		ids = append(ids, id)
+	}
+	rows, err := cfg.db.GetRechirpCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+	counts := map[int32]int64{}
+	for _, row := range rows {
+		counts[row.ChirpID] = row.RechirpCount
+	}
+
+	var bookmarked map[int32]bool
+	if viewer.Valid {
+		bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return err
+		}
+		bookmarked = map[int32]bool{}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	polls, err := cfg.loadPolls(ctx, viewer, ids)
+	if err != nil {
+		return err
+	}
+
+	mediaRows, err := cfg.db.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	attachments := map[int32][]Media{}
+	for _, row := range mediaRows {
+		attachments[row.ChirpID] = append(attachments[row.ChirpID], mediaFromDB(row.Medium))
+	}
+
+	linkRows, err := cfg.db.GetLinksForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	links := map[int32][]Link{}
+	for _, row := range linkRows {
+		links[row.ChirpID] = append(links[row.ChirpID], linkFromDB(row.LinkPreview))
+	}
+
+	decorate := func(chirp *Chirp) {
+		chirp.RechirpCount = counts[chirp.ID]
+		chirp.Poll = polls[chirp.ID]
+		chirp.Media = attachments[chirp.ID]
+		chirp.Links = links[chirp.ID]
+		if bookmarked != nil {
+			b := bookmarked[chirp.ID]
+			chirp.BookmarkedByMe = &b
+		}
+	}
+	for _, q := range quoted {
+		decorate(q)
+	}
+	for i := range chirps {
+		decorate(&chirps[i])
+		if chirps[i].QuoteOf != nil {
+			chirps[i].Quoted = quoted[*chirps[i].QuoteOf]
+		}
+	}
+	return nil
+}
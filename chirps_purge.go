@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	defaultChirpRestoreWindow = 30 * 24 * time.Hour
+	chirpPurgeInterval        = time.Hour
+)
+
+// runChirpPurge periodically hard-deletes chirps whose restore window has
+// passed.
+func (cfg *apiConfig) runChirpPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := cfg.db.PurgeDeletedChirps(ctx, int32(cfg.chirpRestoreWindow.Seconds()))
+		if err != nil {
+			log.Printf("Error purging deleted chirps: %s", err)
+		} else if n > 0 {
+			log.Printf("Purged %d deleted chirps", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
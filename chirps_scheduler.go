@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	maxScheduleAhead        = 365 * 24 * time.Hour
+	chirpSchedulerInterval  = 10 * time.Second
+	chirpSchedulerBatchSize = 100
+)
+
+// runChirpScheduler periodically publishes scheduled chirps that are due.
+// Schedules live in the database and due rows are claimed with
+// FOR UPDATE SKIP LOCKED, so they survive restarts and each chirp is
+// published by exactly one instance.
+func (cfg *apiConfig) runChirpScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirps: %s", err)
+				break
+			}
+			if n < chirpSchedulerBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	dbChirps, err := qtx.PublishDueChirps(ctx, chirpSchedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, dbChirp := range dbChirps {
+		if err := cfg.chirpPublished(ctx, qtx, dbChirp); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(dbChirps), nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	DMPolicyEveryone = "everyone"
+	DMPolicyNobody   = "nobody"
+
+	maxMessageLength = 1000
+
+	defaultConversationsLimit = 20
+	maxConversationsLimit     = 100
+	defaultMessagesLimit      = 50
+	maxMessagesLimit          = 100
+)
+
+type Conversation struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	LastMessage *Message  `json:"last_message"`
+	UnreadCount int64     `json:"unread_count"`
+}
+
+type Message struct {
+	ID             uuid.UUID  `json:"id"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	SenderID       uuid.UUID  `json:"sender_id"`
+	Body           string     `json:"body"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReadAt         *time.Time `json:"read_at"`
+}
+
+// conversationFromDB describes dbConversation from viewerID's side: UserID is
+// the other participant.
+func conversationFromDB(dbConversation database.Conversation, viewerID uuid.UUID) Conversation {
+	otherID := dbConversation.UserAID
+	if otherID == viewerID {
+		otherID = dbConversation.UserBID
+	}
+	return Conversation{
+		ID:        dbConversation.ID,
+		UserID:    otherID,
+		CreatedAt: dbConversation.CreatedAt,
+		UpdatedAt: dbConversation.UpdatedAt,
+	}
+}
+
+func messageFromDB(dbMessage database.Message) Message {
+	message := Message{
+		ID:             dbMessage.ID,
+		ConversationID: dbMessage.ConversationID,
+		SenderID:       dbMessage.SenderID,
+		Body:           dbMessage.Body,
+		CreatedAt:      dbMessage.CreatedAt,
+	}
+	if dbMessage.ReadAt.Valid {
+		message.ReadAt = &dbMessage.ReadAt.Time
+	}
+	return message
+}
+
+// checkCanMessage loads recipientID and checks senderID may message them:
+// neither has blocked the other and the recipient accepts messages. It
+// responds with an error and returns false if not.
+func (cfg *apiConfig) checkCanMessage(w http.ResponseWriter, r *http.Request, senderID, recipientID uuid.UUID) (database.User, bool) {
+	if senderID == recipientID {
+		respondWithError(w, http.StatusBadRequest, "You can't message yourself", nil)
+		return database.User{}, false
+	}
+
+	recipient, err := cfg.db.GetUserByID(r.Context(), recipientID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && recipient.BannedAt.Valid) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return database.User{}, false
+	}
+
+	blocked, err := cfg.db.IsBlockedEitherWay(r.Context(), database.IsBlockedEitherWayParams{
+		UserID:  senderID,
+		OtherID: recipientID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return database.User{}, false
+	}
+	if blocked || recipient.DmPolicy == DMPolicyNobody {
+		respondWithError(w, http.StatusForbidden, "This user isn't accepting messages from you", nil)
+		return database.User{}, false
+	}
+	return recipient, true
+}
+
+// loadLatestMessages fills in LastMessage on each conversation.
+func (cfg *apiConfig) loadLatestMessages(ctx context.Context, conversations []Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+	dbMessages, err := cfg.db.GetLatestMessages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	latest := map[uuid.UUID]Message{}
+	for _, dbMessage := range dbMessages {
+		latest[dbMessage.ConversationID] = messageFromDB(dbMessage)
+	}
+	for i := range conversations {
+		if message, ok := latest[conversations[i].ID]; ok {
+			conversations[i].LastMessage = &message
+		}
+	}
+	return nil
+}
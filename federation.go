@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/activitypub"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	APDeliveryPending   = "pending"
+	APDeliverySucceeded = "succeeded"
+	APDeliveryFailed    = "failed"
+
+	apDeliveryInterval  = 5 * time.Second
+	apDeliveryTimeout   = 10 * time.Second
+	apDeliveryBatchSize = 20
+	// a claimed delivery is retried after the lease if the worker dies mid-send
+	apDeliveryLease = 5 * time.Minute
+	apMaxAttempts   = 8
+
+	apKeyFragment = "#main-key"
+)
+
+// apActorURL is the ActivityPub ID of a user's actor.
+func (cfg *apiConfig) apActorURL(userID uuid.UUID) string {
+	return fmt.Sprintf("%s/ap/users/%s", cfg.baseURL, userID)
+}
+
+// apNoteURL is the ActivityPub ID of a chirp's note.
+func (cfg *apiConfig) apNoteURL(chirpID int32) string {
+	return fmt.Sprintf("%s/ap/chirps/%d", cfg.baseURL, chirpID)
+}
+
+// apKey returns a user's signing key, creating it the first time it's needed.
+func (cfg *apiConfig) apKey(ctx context.Context, userID uuid.UUID) (database.ApKey, error) {
+	key, err := cfg.db.GetAPKey(ctx, userID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return key, err
+	}
+
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.ApKey{}, err
+	}
+	// a concurrent request may have created the key first, in which case
+	// that one is kept
+	if err := cfg.db.CreateAPKey(ctx, database.CreateAPKeyParams{
+		UserID:     userID,
+		PrivateKey: privatePEM,
+		PublicKey:  publicPEM,
+	}); err != nil {
+		return database.ApKey{}, err
+	}
+	return cfg.db.GetAPKey(ctx, userID)
+}
+
+// apSigner returns the signer for a user's actor.
+func (cfg *apiConfig) apSigner(ctx context.Context, userID uuid.UUID) (activitypub.Signer, error) {
+	key, err := cfg.apKey(ctx, userID)
+	if err != nil {
+		return activitypub.Signer{}, err
+	}
+	privateKey, err := activitypub.ParsePrivateKey(key.PrivateKey)
+	if err != nil {
+		return activitypub.Signer{}, err
+	}
+	return activitypub.Signer{KeyID: cfg.apActorURL(userID) + apKeyFragment, Key: privateKey}, nil
+}
+
+func (cfg *apiConfig) apNoteFromChirp(dbChirp database.Chirp) activitypub.Note {
+	actor := cfg.apActorURL(dbChirp.UserID)
+	return activitypub.Note{
+		ID:           cfg.apNoteURL(dbChirp.ID),
+		Type:         activitypub.TypeNote,
+		AttributedTo: actor,
+		// chirps are plain text, notes are HTML
+		Content:   "<p>" + html.EscapeString(dbChirp.Body) + "</p>",
+		URL:       fmt.Sprintf("%s/api/chirps/%d", cfg.baseURL, dbChirp.ID),
+		Published: dbChirp.CreatedAt.UTC().Format(time.RFC3339),
+		To:        []string{activitypub.PublicCollection},
+		Cc:        []string{actor + "/followers"},
+	}
+}
+
+func (cfg *apiConfig) apCreateActivity(dbChirp database.Chirp) (activitypub.Activity, error) {
+	note := cfg.apNoteFromChirp(dbChirp)
+	return activitypub.NewActivity(note.ID+"/activity", activitypub.TypeCreate, note.AttributedTo, note, note.To, note.Cc)
+}
+
+func (cfg *apiConfig) apDeleteActivity(dbChirp database.Chirp) (activitypub.Activity, error) {
+	actor := cfg.apActorURL(dbChirp.UserID)
+	tombstone := activitypub.Note{ID: cfg.apNoteURL(dbChirp.ID), Type: activitypub.TypeTombstone}
+	return activitypub.NewActivity(tombstone.ID+"#delete", activitypub.TypeDelete, actor, tombstone,
+		[]string{activitypub.PublicCollection}, []string{actor + "/followers"})
+}
+
+// federateActivity queues delivery of activity to the inboxes of every remote
+// follower of userID. Like enqueueWebhookEvent, call it with the transaction
+// that made the change.
+func federateActivity(ctx context.Context, q *database.Queries, userID uuid.UUID, activity activitypub.Activity) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	_, err = q.EnqueueAPFollowerDeliveries(ctx, database.EnqueueAPFollowerDeliveriesParams{
+		UserID:  userID,
+		Payload: payload,
+	})
+	return err
+}
+
+// runAPDeliveries periodically sends due ActivityPub deliveries. Deliveries
+// are claimed with row locks, so several instances can run this safely.
+func (cfg *apiConfig) runAPDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := cfg.db.ClaimDueAPDeliveries(ctx, database.ClaimDueAPDeliveriesParams{
+			LeaseSeconds: int32(apDeliveryLease.Seconds()),
+			BatchSize:    apDeliveryBatchSize,
+		})
+		if err != nil {
+			log.Printf("Error claiming ActivityPub deliveries: %s", err)
+		}
+		for _, delivery := range deliveries {
+			cfg.attemptAPDelivery(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) attemptAPDelivery(ctx context.Context, delivery database.ApDelivery) {
+	signer, err := cfg.apSigner(ctx, delivery.UserID)
+	if err != nil {
+		log.Printf("Error loading ActivityPub key for %s: %s", delivery.UserID, err)
+		return
+	}
+
+	statusCode, deliverErr := cfg.apClient.Deliver(ctx, delivery.Inbox, delivery.Payload, signer)
+	lastStatusCode := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+
+	if deliverErr == nil {
+		if err := cfg.db.MarkAPDeliverySucceeded(ctx, database.MarkAPDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: lastStatusCode,
+		}); err != nil {
+			log.Printf("Error recording ActivityPub delivery %s: %s", delivery.ID, err)
+		}
+		return
+	}
+
+	status := APDeliveryPending
+	// client errors other than rate limiting won't go away on retry
+	permanent := statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests
+	if delivery.Attempts >= apMaxAttempts || permanent {
+		status = APDeliveryFailed
+	}
+	if err := cfg.db.MarkAPDeliveryFailed(ctx, database.MarkAPDeliveryFailedParams{
+		ID:                delivery.ID,
+		Status:            status,
+		LastStatusCode:    lastStatusCode,
+		LastError:         sql.NullString{String: deliverErr.Error(), Valid: true},
+		RetryAfterSeconds: int32(webhooks.Backoff(int(delivery.Attempts)).Seconds()),
+	}); err != nil {
+		log.Printf("Error recording ActivityPub delivery %s: %s", delivery.ID, err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+type ModerationRule struct {
+	ID        uuid.UUID         `json:"id"`
+	Term      string            `json:"term"`
+	Action    moderation.Action `json:"action"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type FlaggedChirp struct {
+	Chirp
+	Terms     []string  `json:"terms"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+func moderationRuleFromDB(dbRule database.ModerationRule) ModerationRule {
+	return ModerationRule{
+		ID:        dbRule.ID,
+		Term:      dbRule.Term,
+		Action:    moderation.Action(dbRule.Action),
+		CreatedAt: dbRule.CreatedAt,
+		UpdatedAt: dbRule.UpdatedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerModerationRulesList(w http.ResponseWriter, r *http.Request) {
+	dbRules, err := cfg.db.ListModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve moderation rules", err)
+		return
+	}
+
+	rules := []ModerationRule{}
+	for _, dbRule := range dbRules {
+		rules = append(rules, moderationRuleFromDB(dbRule))
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+func (cfg *apiConfig) handlerModerationRulesCreate(w http.ResponseWriter, r *http.Request) {
+	// parse body
+	type parameters struct {
+		Term   string            `json:"term"`
+		Action moderation.Action `json:"action"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	// validations
+	term, err := moderation.NormalizeTerm(params.Term)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Term must be a single word", err)
+		return
+	}
+	if !params.Action.Valid() {
+		respondWithError(w, http.StatusBadRequest, "Action must be one of mask, reject or flag", nil)
+		return
+	}
+
+	dbRule, err := cfg.db.UpsertModerationRule(r.Context(), database.UpsertModerationRuleParams{
+		Term:   term,
+		Action: string(params.Action),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save moderation rule", err)
+		return
+	}
+
+	if err := cfg.reloadModerationRules(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, moderationRuleFromDB(dbRule))
+}
+
+func (cfg *apiConfig) handlerModerationRulesDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid rule ID", err)
+		return
+	}
+
+	n, err := cfg.db.DeleteModerationRule(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete moderation rule", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Moderation rule not found", nil)
+		return
+	}
+
+	if err := cfg.reloadModerationRules(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerFlaggedChirpsList(w http.ResponseWriter, r *http.Request) {
+	dbFlagged, err := cfg.db.ListFlaggedChirps(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve flagged chirps", err)
+		return
+	}
+
+	flagged := []FlaggedChirp{}
+	for _, row := range dbFlagged {
+		flagged = append(flagged, FlaggedChirp{
+			Chirp:     chirpFromDB(row.Chirp),
+			Terms:     row.Terms,
+			FlaggedAt: row.FlaggedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, flagged)
+}
+
+func (cfg *apiConfig) handlerFlaggedChirpsDismiss(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	n, err := cfg.db.DeleteChirpFlag(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to dismiss flag", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Flag not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUsersSetModerator(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		IsModerator bool `json:"is_moderator"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.SetUserModerator(r.Context(), database.SetUserModeratorParams{
+		ID:          id,
+		IsModerator: params.IsModerator,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	action := ModActionRevokeModerator
+	if params.IsModerator {
+		action = ModActionGrantModerator
+	}
+	if err := recordModerationAction(r.Context(), qtx, nil, action, ModTargetUser, id.String(), ""); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
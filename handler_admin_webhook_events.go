@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebhookEventsLimit = 50
+	maxWebhookEventsLimit     = 200
+)
+
+type WebhookEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	Provider    string          `json:"provider"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+func webhookEventFromDB(dbEvent database.WebhookEvent) WebhookEvent {
+	event := WebhookEvent{
+		ID:         dbEvent.ID,
+		Provider:   dbEvent.Provider,
+		EventID:    dbEvent.EventID,
+		EventType:  dbEvent.EventType,
+		Payload:    dbEvent.Payload,
+		Status:     dbEvent.Status,
+		Error:      dbEvent.Error.String,
+		Attempts:   dbEvent.Attempts,
+		ReceivedAt: dbEvent.ReceivedAt,
+	}
+	if dbEvent.ProcessedAt.Valid {
+		event.ProcessedAt = &dbEvent.ProcessedAt.Time
+	}
+	return event
+}
+
+func (cfg *apiConfig) handlerWebhookEventsList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	limit := defaultWebhookEventsLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxWebhookEventsLimit)
+	}
+
+	dbEvents, err := cfg.db.ListWebhookEvents(r.Context(), database.ListWebhookEventsParams{
+		Status: sql.NullString{String: status, Valid: status != ""},
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook events", err)
+		return
+	}
+
+	events := []WebhookEvent{}
+	for _, dbEvent := range dbEvents {
+		events = append(events, webhookEventFromDB(dbEvent))
+	}
+
+	respondWithJSON(w, http.StatusOK, events)
+}
+
+func (cfg *apiConfig) handlerWebhookEventRetry(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid event ID", err)
+		return
+	}
+
+	dbEvent, err := cfg.db.GetWebhookEventByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Webhook event not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook event", err)
+		return
+	}
+	abandoned := dbEvent.Status == WebhookStatusProcessing && time.Since(dbEvent.UpdatedAt) > webhookEventLease
+	if dbEvent.Status != WebhookStatusFailed && !abandoned {
+		respondWithError(w, http.StatusConflict, "Only failed or abandoned webhook events can be retried", nil)
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), dbEvent)
+	if errors.Is(err, errWebhookEventNotClaimable) {
+		respondWithError(w, http.StatusConflict, "Webhook event is already being processed", err)
+		return
+	}
+	if err != nil {
+		// processing failures are recorded on the event, which is returned below
+		log.Printf("retrying webhook event %s: %v", id, err)
+	}
+
+	dbEvent, err = cfg.db.GetWebhookEventByID(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook event", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhookEventFromDB(dbEvent))
+}
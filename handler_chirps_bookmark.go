@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultBookmarksLimit = 20
+	maxBookmarksLimit     = 100
+)
+
+type Bookmark struct {
+	CreatedAt time.Time `json:"created_at"`
+	Chirp     Chirp     `json:"chirp"`
+}
+
+func (cfg *apiConfig) handlerChirpsBookmark(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetShareableChirpByID(r.Context(), database.GetShareableChirpByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+
+	// bookmarking twice is a no-op
+	if err := cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: dbChirp.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpsUnbookmark(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	n, err := cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: int32(id),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Bookmark not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUsersBookmarks(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	limit := defaultBookmarksLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxBookmarksLimit)
+	}
+
+	// before and before_id are the created_at and chirp id of the last
+	// bookmark on the previous page
+	before := sql.NullTime{}
+	if b := r.URL.Query().Get("before"); b != "" {
+		t, err := time.Parse(time.RFC3339Nano, b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before timestamp", err)
+			return
+		}
+		before = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	beforeID := sql.NullInt32{}
+	if b := r.URL.Query().Get("before_id"); b != "" {
+		id, err := strconv.Atoi(b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before_id", err)
+			return
+		}
+		if !before.Valid {
+			respondWithError(w, http.StatusBadRequest, "before_id requires before", nil)
+			return
+		}
+		beforeID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarksByUserID(r.Context(), database.GetBookmarksByUserIDParams{
+		UserID:   userID,
+		Before:   before,
+		BeforeID: beforeID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, chirpFromDB(row.Chirp))
+	}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	bookmarks := []Bookmark{}
+	for i, row := range rows {
+		bookmarks = append(bookmarks, Bookmark{
+			CreatedAt: row.BookmarkedAt,
+			Chirp:     chirps[i],
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, bookmarks)
+}
@@ -1,23 +1,89 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/chirptext"
 	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/linkpreview"
+	"github.com/bontaramsonta/go-chirpy/internal/moderation"
 	"github.com/google/uuid"
 )
 
 type Chirp struct {
-	ID        int32     `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	Body      string    `json:"body"`
+	ID        int32      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Body      string     `json:"body"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	QuoteOf   *int32     `json:"quote_of,omitempty"`
+	// Quoted embeds the chirp referenced by QuoteOf. It is left out once that
+	// chirp is deleted, hidden or otherwise not visible to the caller.
+	Quoted       *Chirp  `json:"quoted,omitempty"`
+	RechirpCount int64   `json:"rechirp_count"`
+	Poll         *Poll   `json:"poll,omitempty"`
+	Media        []Media `json:"media,omitempty"`
+	Links        []Link  `json:"links,omitempty"`
+	// Pinned is only set on an author's pinned chirp in author listings.
+	Pinned bool `json:"pinned,omitempty"`
+	// BookmarkedByMe is only set for authenticated callers.
+	BookmarkedByMe *bool `json:"bookmarked_by_me,omitempty"`
+}
+
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:        dbChirp.ID,
+		CreatedAt: dbChirp.CreatedAt,
+		UpdatedAt: dbChirp.UpdatedAt,
+		UserID:    dbChirp.UserID,
+		Body:      dbChirp.Body,
+	}
+	if dbChirp.PublishAt.Valid {
+		chirp.PublishAt = &dbChirp.PublishAt.Time
+	}
+	if dbChirp.QuoteOf.Valid {
+		chirp.QuoteOf = &dbChirp.QuoteOf.Int32
+	}
+	return chirp
+}
+
+// chirpRequestError is a createChirp failure caused by the request rather
+// than the server, carrying the status code to respond with.
+type chirpRequestError struct {
+	status int
+	msg    string
+}
+
+func (e *chirpRequestError) Error() string {
+	return e.msg
+}
+
+const (
+	ChirpErrorEmpty      = "empty"
+	ChirpErrorTooLong    = "too_long"
+	ChirpErrorProhibited = "prohibited_content"
+)
+
+// chirpValidationError is a chirp body that failed validation. It is sent to
+// the client as is, with the body's counted length so clients can show how
+// far over the limit it is.
+type chirpValidationError struct {
+	Msg       string `json:"error"`
+	Code      string `json:"code"`
+	Length    int    `json:"length"`
+	MaxLength int    `json:"max_length"`
+}
+
+func (e *chirpValidationError) Error() string {
+	return e.Msg
 }
 
 func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +91,11 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
 	// parse body
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string      `json:"body"`
+		PublishAt *time.Time  `json:"publish_at"`
+		QuoteOf   *int32      `json:"quote_of"`
+		Poll      *pollParams `json:"poll"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -35,54 +105,231 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
-	// validate
-	cleaned, err := validateChirp(params.Body)
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
+	chirp, err := cfg.createChirp(r.Context(), qtx, userID, createChirpParams{
+		Body:      params.Body,
+		PublishAt: params.PublishAt,
+		QuoteOf:   params.QuoteOf,
+		Poll:      params.Poll,
+		MediaIDs:  params.MediaIDs,
 	})
 	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	chirps := []Chirp{chirp}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
+
+type createChirpParams struct {
+	Body      string
+	PublishAt *time.Time
+	QuoteOf   *int32
+	Poll      *pollParams
+	MediaIDs  []uuid.UUID
 }
 
-func validateChirp(body string) (string, error) {
-	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+// createChirp checks the user's plan limits, validates and moderates body and
+// stores the chirp using qtx, which should belong to a transaction.
+func (cfg *apiConfig) createChirp(ctx context.Context, qtx *database.Queries, userID uuid.UUID, params createChirpParams) (Chirp, error) {
+	// check plan limits
+	limits, err := cfg.userLimits(ctx, userID)
+	if err != nil {
+		return Chirp{}, fmt.Errorf("couldn't load plan limits: %w", err)
+	}
+	if limits.ChirpsPerHour > 0 {
+		recent, err := qtx.CountRecentChirpsByAuthor(ctx, userID)
+		if err != nil {
+			return Chirp{}, fmt.Errorf("couldn't check chirp rate limit: %w", err)
+		}
+		if recent >= int64(limits.ChirpsPerHour) {
+			return Chirp{}, &chirpRequestError{http.StatusTooManyRequests, "Chirp rate limit exceeded"}
+		}
+	}
+
+	// validate
+	moderated, err := cfg.validateChirp(params.Body, limits.MaxChirpLength)
+	if err != nil {
+		return Chirp{}, err
+	}
+
+	publishAt := sql.NullTime{}
+	if params.PublishAt != nil {
+		if !limits.CanScheduleChirps {
+			return Chirp{}, &chirpRequestError{http.StatusForbidden, "Your plan doesn't allow scheduling chirps"}
+		}
+		now := time.Now()
+		if !params.PublishAt.After(now) {
+			return Chirp{}, &chirpRequestError{http.StatusBadRequest, "publish_at must be in the future"}
+		}
+		if params.PublishAt.After(now.Add(maxScheduleAhead)) {
+			return Chirp{}, &chirpRequestError{http.StatusBadRequest, "publish_at is too far in the future"}
+		}
+		publishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
+
+	quoteOf := sql.NullInt32{}
+	if params.QuoteOf != nil {
+		quoted, err := qtx.GetShareableChirpByID(ctx, database.GetShareableChirpByIDParams{
+			ID:     *params.QuoteOf,
+			UserID: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return Chirp{}, &chirpRequestError{http.StatusBadRequest, "Quoted chirp not found"}
+		}
+		if err != nil {
+			return Chirp{}, fmt.Errorf("couldn't load quoted chirp: %w", err)
+		}
+		quoteOf = sql.NullInt32{Int32: quoted.ID, Valid: true}
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	if err := checkChirpMedia(ctx, qtx, userID, params.MediaIDs); err != nil {
+		return Chirp{}, err
 	}
-	cleaned := getCleanedBody(body, badWords)
-	return cleaned, nil
+
+	dbChirp, err := qtx.CreateChirp(ctx, database.CreateChirpParams{
+		Body:      moderated.Body,
+		UserID:    userID,
+		PublishAt: publishAt,
+		QuoteOf:   quoteOf,
+	})
+	if err != nil {
+		return Chirp{}, err
+	}
+	if err := storeChirpLinks(ctx, qtx, dbChirp.ID, dbChirp.Body); err != nil {
+		return Chirp{}, err
+	}
+
+	if moderated.Flagged {
+		if err := qtx.FlagChirp(ctx, database.FlagChirpParams{
+			ChirpID: dbChirp.ID,
+			Terms:   flaggedTerms(moderated),
+		}); err != nil {
+			return Chirp{}, err
+		}
+	}
+
+	for i, mediaID := range params.MediaIDs {
+		if err := qtx.AttachMediaToChirp(ctx, database.AttachMediaToChirpParams{
+			ChirpID:  dbChirp.ID,
+			MediaID:  mediaID,
+			Position: int32(i),
+		}); err != nil {
+			return Chirp{}, err
+		}
+	}
+
+	if params.Poll != nil {
+		opensAt := time.Now()
+		if publishAt.Valid {
+			opensAt = publishAt.Time
+		}
+		if err := cfg.createPoll(ctx, qtx, dbChirp.ID, opensAt, *params.Poll); err != nil {
+			return Chirp{}, err
+		}
+	}
+
+	// scheduled chirps announce themselves when the scheduler publishes them
+	if !publishAt.Valid {
+		if err := cfg.chirpPublished(ctx, qtx, dbChirp); err != nil {
+			return Chirp{}, err
+		}
+	}
+
+	return chirpFromDB(dbChirp), nil
 }
 
-func getCleanedBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
+// chirpPublished runs the side effects of a chirp going public using qtx, so
+// they commit with the chirp: webhooks, delivery to remote followers and
+// notifying the author of a quoted chirp.
+func (cfg *apiConfig) chirpPublished(ctx context.Context, qtx *database.Queries, dbChirp database.Chirp) error {
+	if err := enqueueWebhookEvent(ctx, qtx, WebhookEventChirpCreated, uuid.NullUUID{}, chirpFromDB(dbChirp)); err != nil {
+		return fmt.Errorf("couldn't queue webhooks: %w", err)
+	}
+	if err := recordChirpEvent(ctx, qtx, ChirpEventCreated, dbChirp); err != nil {
+		return fmt.Errorf("couldn't record chirp event: %w", err)
+	}
+	activity, err := cfg.apCreateActivity(dbChirp)
+	if err != nil {
+		return err
+	}
+	if err := federateActivity(ctx, qtx, dbChirp.UserID, activity); err != nil {
+		return fmt.Errorf("couldn't queue federation: %w", err)
+	}
+
+	if dbChirp.QuoteOf.Valid {
+		quoted, err := qtx.GetChirpByID(ctx, dbChirp.QuoteOf.Int32)
+		// the quoted chirp may have been deleted since
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := notify(ctx, qtx, quoted.UserID, NotificationTypeQuote, quoted.ID, dbChirp.UserID); err != nil {
+			return fmt.Errorf("couldn't create notification: %w", err)
 		}
 	}
-	cleaned := strings.Join(words, " ")
-	return cleaned
+	return nil
+}
+
+func respondWithChirpError(w http.ResponseWriter, err error) {
+	var valErr *chirpValidationError
+	if errors.As(err, &valErr) {
+		respondWithJSON(w, http.StatusBadRequest, valErr)
+		return
+	}
+	var reqErr *chirpRequestError
+	if errors.As(err, &reqErr) {
+		respondWithError(w, reqErr.status, reqErr.msg, err)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+}
+
+// chirpLength is body's length towards the chirp length limit, counted in
+// user-perceived characters, in which every URL counts as linkLength.
+func chirpLength(body string) int {
+	length := chirptext.Length(body)
+	for _, url := range linkpreview.Find(body) {
+		length += linkLength - chirptext.Length(url)
+	}
+	return length
+}
+
+// validateChirp normalizes body and checks its length and content, returning
+// the moderated, normalized body. Failures are *chirpValidationError.
+func (cfg *apiConfig) validateChirp(body string, maxChirpLength int) (moderation.Result, error) {
+	body = chirptext.Normalize(body)
+	length := chirpLength(body)
+	if body == "" {
+		return moderation.Result{}, &chirpValidationError{"Chirp is empty", ChirpErrorEmpty, length, maxChirpLength}
+	}
+	if length > maxChirpLength {
+		return moderation.Result{}, &chirpValidationError{"Chirp is too long", ChirpErrorTooLong, length, maxChirpLength}
+	}
+
+	res := cfg.moderator.Moderate(body)
+	if res.Rejected {
+		return moderation.Result{}, &chirpValidationError{"Chirp contains prohibited content", ChirpErrorProhibited, length, maxChirpLength}
+	}
+	return res, nil
 }
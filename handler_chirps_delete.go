@@ -1,10 +1,13 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
@@ -28,7 +31,7 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 	// get chirp from database
 	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		cfg.respondChirpNotFound(w, r, int32(id), err)
 		return
 	}
 
@@ -38,12 +41,123 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// delete chirp from database
-	err = cfg.db.DeleteChirp(r.Context(), int32(id))
+	// a scheduled chirp was never public, so it is deleted outright with
+	// nothing to announce
+	if dbChirp.PublishAt.Valid {
+		n, err := cfg.db.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+			ID:     int32(id),
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
+			return
+		}
+		if n > 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// published since it was read, so delete it like any other chirp
+		dbChirp, err = cfg.db.GetChirpByID(r.Context(), int32(id))
+		if err != nil {
+			cfg.respondChirpNotFound(w, r, int32(id), err)
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// soft-delete chirp; it can be restored until the purge job removes it
+	err = qtx.DeleteChirp(r.Context(), int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
 		return
 	}
 
+	if err := enqueueWebhookEvent(r.Context(), qtx, WebhookEventChirpDeleted, uuid.NullUUID{}, chirpFromDB(dbChirp)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue webhooks", err)
+		return
+	}
+	if err := recordChirpEvent(r.Context(), qtx, ChirpEventDeleted, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record chirp event", err)
+		return
+	}
+	activity, err := cfg.apDeleteActivity(dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue federation", err)
+		return
+	}
+	if err := federateActivity(r.Context(), qtx, dbChirp.UserID, activity); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue federation", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (cfg *apiConfig) handlerChirpsRestore(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetDeletedChirpByID(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Deleted chirp not found", err)
+		return
+	}
+
+	// check if user is the owner of the chirp
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You are not the owner of this chirp", nil)
+		return
+	}
+
+	dbChirp, err = cfg.db.RestoreDeletedChirp(r.Context(), database.RestoreDeletedChirpParams{
+		ID:            int32(id),
+		WindowSeconds: int32(cfg.chirpRestoreWindow.Seconds()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusGone, "The restore window for this chirp has passed", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirpFromDB(dbChirp))
+}
+
+// handlerChirpsActionDelete routes DELETE /api/chirps/{chirpID}/{action}.
+// ServeMux won't register DELETE /api/chirps/{chirpID}/rechirp next to
+// DELETE /api/chirps/scheduled/{chirpID}, as neither is more specific than
+// the other, so the undo routes share a pattern the scheduled one refines.
+func (cfg *apiConfig) handlerChirpsActionDelete(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("action") {
+	case "rechirp":
+		cfg.handlerChirpsUnrechirp(w, r)
+	case "bookmark":
+		cfg.handlerChirpsUnbookmark(w, r)
+	case "pin":
+		cfg.handlerChirpsUnpin(w, r)
+	case "schedule":
+		cfg.handlerChirpsScheduledDelete(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"sort"
 	"strconv"
 
+	"github.com/bontaramsonta/go-chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
@@ -14,51 +18,27 @@ const (
 )
 
 func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Request) {
-	authorID := r.URL.Query().Get("author_id")
 	s := r.URL.Query().Get("sort")
+	viewer := viewerID(r)
 
-	chirps := []Chirp{}
-
-	if authorID == "" {
-		// get all chirps
-		dbChirps, err := cfg.db.GetAllChirps(r.Context())
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
-			return
-		}
-
-		for _, dbChirp := range dbChirps {
-			chirps = append(chirps, Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				UserID:    dbChirp.UserID,
-				Body:      dbChirp.Body,
-			})
-		}
-	} else {
-		// get chirps by author ID
-		authorUUID, err := uuid.Parse(authorID)
+	authorID := uuid.NullUUID{}
+	if a := r.URL.Query().Get("author_id"); a != "" {
+		id, err := uuid.Parse(a)
 		if err != nil {
 			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
 
-		dbChirps, err := cfg.db.GetChirpsByAuthorID(r.Context(), authorUUID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps for author", err)
-			return
-		}
-
-		for _, dbChirp := range dbChirps {
-			chirps = append(chirps, Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				UserID:    dbChirp.UserID,
-				Body:      dbChirp.Body,
-			})
+	chirps, pinnedID, err := cfg.listChirps(r.Context(), viewer, authorID)
+	if err != nil {
+		msg := "Couldn't retrieve chirps"
+		if authorID.Valid {
+			msg = "Couldn't retrieve chirps for author"
 		}
+		respondWithError(w, http.StatusInternalServerError, msg, err)
+		return
 	}
 
 	if s != SortAsc && s != SortDesc {
@@ -73,9 +53,66 @@ func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Reque
 		return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
 	})
 
+	// the author's pinned chirp leads regardless of sort order
+	if pinnedID.Valid {
+		for i := range chirps {
+			if chirps[i].ID == pinnedID.Int32 {
+				pinned := chirps[i]
+				pinned.Pinned = true
+				copy(chirps[1:i+1], chirps[:i])
+				chirps[0] = pinned
+				break
+			}
+		}
+	}
+
+	if err := cfg.decorateChirps(r.Context(), viewer, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, chirps)
 }
 
+// listChirps runs the queries behind GET /api/chirps: every chirp viewer can
+// see, or only authorID's along with their pinned chirp's ID. The chirps are
+// not sorted or decorated.
+func (cfg *apiConfig) listChirps(ctx context.Context, viewer uuid.NullUUID, authorID uuid.NullUUID) ([]Chirp, sql.NullInt32, error) {
+	chirps := []Chirp{}
+
+	if !authorID.Valid {
+		// get all chirps
+		dbChirps, err := cfg.db.GetAllChirps(ctx, database.GetAllChirpsParams{
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, sql.NullInt32{}, err
+		}
+		for _, dbChirp := range dbChirps {
+			chirps = append(chirps, chirpFromDB(dbChirp))
+		}
+		return chirps, sql.NullInt32{}, nil
+	}
+
+	// get chirps by author ID
+	dbChirps, err := cfg.db.GetChirpsByAuthorID(ctx, database.GetChirpsByAuthorIDParams{
+		UserID:   authorID.UUID,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, sql.NullInt32{}, err
+	}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+
+	pinnedID, err := cfg.db.GetPinnedChirpID(ctx, authorID.UUID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, sql.NullInt32{}, err
+	}
+	return chirps, pinnedID, nil
+}
+
 func (cfg *apiConfig) handlerChirpRetrieve(w http.ResponseWriter, r *http.Request) {
 	chirpId := r.PathValue("chirpID")
 	if chirpId == "" {
@@ -91,17 +128,36 @@ func (cfg *apiConfig) handlerChirpRetrieve(w http.ResponseWriter, r *http.Reques
 
 	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+	if dbChirp.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	// scheduled chirps are only visible to their author until published
+	if viewer := viewerID(r); dbChirp.PublishAt.Valid && (!viewer.Valid || viewer.UUID != dbChirp.UserID) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
 		return
 	}
 
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		UserID:    dbChirp.UserID,
-		Body:      dbChirp.Body,
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	if err := cfg.decorateChirps(r.Context(), viewerID(r), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirp)
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+// respondChirpNotFound responds 410 Gone when the chirp was soft-deleted and
+// 404 otherwise.
+func (cfg *apiConfig) respondChirpNotFound(w http.ResponseWriter, r *http.Request, id int32, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, deletedErr := cfg.db.GetDeletedChirpByID(r.Context(), id); deletedErr == nil {
+			respondWithError(w, http.StatusGone, "Chirp has been deleted", err)
+			return
+		}
+	}
+	respondWithError(w, http.StatusNotFound, "Chirp not found", err)
 }
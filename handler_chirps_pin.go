@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerChirpsPin(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+	if dbChirp.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	// check if user is the owner of the chirp
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You are not the owner of this chirp", nil)
+		return
+	}
+	if dbChirp.PublishAt.Valid {
+		respondWithError(w, http.StatusBadRequest, "Scheduled chirps can't be pinned", nil)
+		return
+	}
+
+	// pinning replaces any previously pinned chirp
+	if err := cfg.db.PinChirp(r.Context(), database.PinChirpParams{
+		ID:            userID,
+		PinnedChirpID: sql.NullInt32{Int32: dbChirp.ID, Valid: true},
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpsUnpin(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	n, err := cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		ID:            userID,
+		PinnedChirpID: sql.NullInt32{Int32: int32(id), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp is not pinned", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerChirpsRechirp(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// hidden, scheduled and blocked-author chirps can't be rechirped
+	dbChirp, err := cfg.db.GetShareableChirpByID(r.Context(), database.GetShareableChirpByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// rechirping twice is a no-op
+	n, err := qtx.Rechirp(r.Context(), database.RechirpParams{
+		UserID:  userID,
+		ChirpID: dbChirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+	if n > 0 {
+		if err := notify(r.Context(), qtx, dbChirp.UserID, NotificationTypeRechirp, dbChirp.ID, userID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create notification", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpsUnrechirp(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// undoing is allowed even after the original is deleted or hidden
+	n, err := cfg.db.Unrechirp(r.Context(), database.UnrechirpParams{
+		UserID:  userID,
+		ChirpID: int32(id),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Rechirp not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// handlerChirpsStream streams chirp.created and chirp.deleted events as
+// Server-Sent Events. Clients that reconnect with Last-Event-ID are sent
+// what they missed first; clients that fall too far behind are disconnected
+// and can resume the same way.
+func (cfg *apiConfig) handlerChirpsStream(w http.ResponseWriter, r *http.Request) {
+	authorID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	resumeFrom := int64(-1)
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid last event ID", err)
+			return
+		}
+		resumeFrom = id
+	}
+
+	// blocked and muted authors are left out, as in the timeline
+	hidden := map[uuid.UUID]bool{}
+	if viewer := viewerID(r); viewer.Valid {
+		ids, err := cfg.db.GetHiddenAuthorIDs(r.Context(), viewer.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't open chirp stream", err)
+			return
+		}
+		for _, id := range ids {
+			hidden[id] = true
+		}
+	}
+
+	// subscribe before replaying so nothing recorded in between is missed
+	sub := cfg.chirpStream.Subscribe()
+	defer cfg.chirpStream.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	// streams outlive any server-wide write timeout
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	send := func(event stream.Event) bool {
+		if event.Type != ChirpEventCreated && event.Type != ChirpEventDeleted {
+			return true
+		}
+		if authorID.Valid && event.UserID != authorID.UUID {
+			return true
+		}
+		if hidden[event.UserID] {
+			return true
+		}
+		if err := stream.WriteEvent(w, event); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	// event ids are stream positions, assigned in the order events are
+	// published, so everything after the client's last one is what it missed
+	lastSent := resumeFrom
+	if resumeFrom >= 0 {
+		for {
+			dbEvents, err := cfg.db.GetChirpEventsAfter(r.Context(), database.GetChirpEventsAfterParams{
+				Seq:   lastSent,
+				Limit: chirpStreamReplayLimit,
+			})
+			if err != nil {
+				return
+			}
+			for _, dbEvent := range dbEvents {
+				if !send(streamEventFromDB(dbEvent)) {
+					return
+				}
+				lastSent = dbEvent.Seq.Int64
+			}
+			if len(dbEvents) < chirpStreamReplayLimit {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(chirpStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := stream.WriteComment(w, "heartbeat"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// dropped for falling behind; the client resumes with
+				// Last-Event-ID
+				return
+			}
+			// already sent during the replay
+			if event.ID <= lastSent {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		}
+	}
+}
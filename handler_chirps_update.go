@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerChirpsUpdate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	chirpId := r.PathValue("chirpID")
+	if chirpId == "" {
+		respondWithError(w, http.StatusBadRequest, "Chirp ID is required", nil)
+		return
+	}
+
+	id, err := strconv.Atoi(chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// get chirp from database
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+
+	// check if user is the owner of the chirp
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You are not the owner of this chirp", nil)
+		return
+	}
+
+	// check plan limits
+	limits, err := cfg.userLimits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load plan limits", err)
+		return
+	}
+	if !limits.CanEditChirps {
+		respondWithError(w, http.StatusForbidden, "Your plan doesn't allow editing chirps", nil)
+		return
+	}
+
+	// validate
+	moderated, err := cfg.validateChirp(params.Body, limits.MaxChirpLength)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	// the new body, its links and its flag are saved together, so the flag
+	// always describes the body moderators see
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.UpdateChirp(r.Context(), database.UpdateChirpParams{
+		ID:   int32(id),
+		Body: moderated.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	if err := storeChirpLinks(r.Context(), qtx, chirp.ID, chirp.Body); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp links", err)
+		return
+	}
+
+	if moderated.Flagged {
+		if err := qtx.FlagChirp(r.Context(), database.FlagChirpParams{
+			ChirpID: chirp.ID,
+			Terms:   flaggedTerms(moderated),
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't flag chirp for review", err)
+			return
+		}
+	} else {
+		// a clean edit clears the flag the old body earned
+		if _, err := qtx.DeleteChirpFlag(r.Context(), chirp.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't clear chirp flag", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	chirps := []Chirp{chirpFromDB(chirp)}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
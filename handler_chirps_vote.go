@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerChirpsVote(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		OptionID int32 `json:"option_id"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetShareableChirpByID(r.Context(), database.GetShareableChirpByIDParams{
+		ID:     int32(id),
+		UserID: userID,
+	})
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+
+	poll, err := cfg.db.GetPollByChirpID(r.Context(), dbChirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp doesn't have a poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve poll", err)
+		return
+	}
+	if !poll.ClosesAt.After(time.Now().UTC()) {
+		respondWithError(w, http.StatusConflict, "Poll has closed", nil)
+		return
+	}
+
+	option, err := cfg.db.GetPollOptionByID(r.Context(), database.GetPollOptionByIDParams{
+		ID:      params.OptionID,
+		ChirpID: dbChirp.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Invalid poll option", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve poll", err)
+		return
+	}
+
+	// the unique constraint on (chirp_id, user_id) keeps it to one vote each
+	_, err = cfg.db.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  dbChirp.ID,
+		UserID:   userID,
+		OptionID: option.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "You have already voted in this poll", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record vote", err)
+		return
+	}
+
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/chirptext"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerConversationsCreate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if _, ok := cfg.checkCanMessage(w, r, userID, params.UserID); !ok {
+		return
+	}
+
+	// starting a conversation that already exists returns it
+	dbConversation, err := cfg.db.GetConversationBetween(r.Context(), database.GetConversationBetweenParams{
+		UserID:  userID,
+		OtherID: params.UserID,
+	})
+	if err == nil {
+		respondWithJSON(w, http.StatusOK, conversationFromDB(dbConversation, userID))
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start conversation", err)
+		return
+	}
+
+	dbConversation, err = cfg.db.CreateConversation(r.Context(), database.CreateConversationParams{
+		UserID:  userID,
+		OtherID: params.UserID,
+	})
+	// the other user started it at the same moment
+	if errors.Is(err, sql.ErrNoRows) {
+		dbConversation, err = cfg.db.GetConversationBetween(r.Context(), database.GetConversationBetweenParams{
+			UserID:  userID,
+			OtherID: params.UserID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start conversation", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, conversationFromDB(dbConversation, userID))
+}
+
+func (cfg *apiConfig) handlerConversationsRetrieve(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	limit := defaultConversationsLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxConversationsLimit)
+	}
+
+	// before and before_id are the updated_at and id of the last
+	// conversation on the previous page
+	before, beforeID, ok := parseBeforeCursor(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetConversationsForUser(r.Context(), database.GetConversationsForUserParams{
+		UserID:   userID,
+		Before:   before,
+		BeforeID: beforeID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	conversations := []Conversation{}
+	for _, row := range rows {
+		conversation := conversationFromDB(row.Conversation, userID)
+		conversation.UnreadCount = row.UnreadCount
+		conversations = append(conversations, conversation)
+	}
+	if err := cfg.loadLatestMessages(r.Context(), conversations); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, conversations)
+}
+
+// conversationForUser loads the conversation named in the path if userID
+// takes part in it. It responds with an error and returns false if not.
+func (cfg *apiConfig) conversationForUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.Conversation, bool) {
+	// get conversationID from path
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return database.Conversation{}, false
+	}
+
+	dbConversation, err := cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Conversation not found", err)
+		return database.Conversation{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", err)
+		return database.Conversation{}, false
+	}
+	return dbConversation, true
+}
+
+func (cfg *apiConfig) handlerConversationMessagesRetrieve(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbConversation, ok := cfg.conversationForUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	limit := defaultMessagesLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxMessagesLimit)
+	}
+
+	// before and before_id are the created_at and id of the oldest message
+	// on the previous page
+	before, beforeID, ok := parseBeforeCursor(w, r)
+	if !ok {
+		return
+	}
+
+	dbMessages, err := cfg.db.GetMessages(r.Context(), database.GetMessagesParams{
+		ConversationID: dbConversation.ID,
+		Before:         before,
+		BeforeID:       beforeID,
+		Limit:          int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+
+	messages := []Message{}
+	for _, dbMessage := range dbMessages {
+		messages = append(messages, messageFromDB(dbMessage))
+	}
+
+	respondWithJSON(w, http.StatusOK, messages)
+}
+
+func (cfg *apiConfig) handlerConversationMessagesCreate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// messages are normalized and counted like chirps
+	body := chirptext.Normalize(params.Body)
+	if body == "" {
+		respondWithError(w, http.StatusBadRequest, "Message is empty", nil)
+		return
+	}
+	if chirptext.Length(body) > maxMessageLength {
+		respondWithError(w, http.StatusBadRequest, "Message is too long", nil)
+		return
+	}
+
+	dbConversation, ok := cfg.conversationForUser(w, r, userID)
+	if !ok {
+		return
+	}
+	recipientID := conversationFromDB(dbConversation, userID).UserID
+
+	// blocks and the recipient's settings apply to existing conversations too
+	recipient, ok := cfg.checkCanMessage(w, r, userID, recipientID)
+	if !ok {
+		return
+	}
+
+	// recipients who keep the safety filter on get the chirp word list applied
+	if recipient.DmFilter {
+		moderated := cfg.moderator.Moderate(body)
+		if moderated.Rejected {
+			respondWithError(w, http.StatusBadRequest, "Message contains prohibited content", nil)
+			return
+		}
+		body = moderated.Body
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	dbMessage, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: dbConversation.ID,
+		SenderID:       userID,
+		Body:           body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	if err := qtx.TouchConversation(r.Context(), dbConversation.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, messageFromDB(dbMessage))
+}
+
+func (cfg *apiConfig) handlerConversationMarkRead(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbConversation, ok := cfg.conversationForUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: dbConversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseBeforeCursor reads the ?before= and ?before_id= keyset cursor shared by
+// the conversation and message lists. It reports false after responding with
+// an error.
+func parseBeforeCursor(w http.ResponseWriter, r *http.Request) (sql.NullTime, uuid.NullUUID, bool) {
+	before := sql.NullTime{}
+	if b := r.URL.Query().Get("before"); b != "" {
+		t, err := time.Parse(time.RFC3339Nano, b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before timestamp", err)
+			return sql.NullTime{}, uuid.NullUUID{}, false
+		}
+		before = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	beforeID := uuid.NullUUID{}
+	if b := r.URL.Query().Get("before_id"); b != "" {
+		id, err := uuid.Parse(b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before_id", err)
+			return sql.NullTime{}, uuid.NullUUID{}, false
+		}
+		if !before.Valid {
+			respondWithError(w, http.StatusBadRequest, "before_id requires before", nil)
+			return sql.NullTime{}, uuid.NullUUID{}, false
+		}
+		beforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	return before, beforeID, true
+}
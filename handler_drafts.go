@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Draft struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Body      string    `json:"body"`
+}
+
+func draftFromDB(dbDraft database.Draft) Draft {
+	return Draft{
+		ID:        dbDraft.ID,
+		CreatedAt: dbDraft.CreatedAt,
+		UpdatedAt: dbDraft.UpdatedAt,
+		UserID:    dbDraft.UserID,
+		Body:      dbDraft.Body,
+	}
+}
+
+// validateDraft applies the chirp rules to a draft body. Drafts keep the body
+// as written; masking happens when the draft is published.
+func (cfg *apiConfig) validateDraft(ctx context.Context, userID uuid.UUID, body string) error {
+	limits, err := cfg.userLimits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.validateChirp(body, limits.MaxChirpLength); err != nil {
+		return err
+	}
+	return nil
+}
+
+func respondWithDraftError(w http.ResponseWriter, err error) {
+	var valErr *chirpValidationError
+	if errors.As(err, &valErr) {
+		respondWithJSON(w, http.StatusBadRequest, valErr)
+		return
+	}
+	var reqErr *chirpRequestError
+	if errors.As(err, &reqErr) {
+		respondWithError(w, reqErr.status, reqErr.msg, err)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+}
+
+func (cfg *apiConfig) handlerDraftsCreate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// parse body
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if err := cfg.validateDraft(r.Context(), userID, params.Body); err != nil {
+		respondWithDraftError(w, err)
+		return
+	}
+
+	dbDraft, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID: userID,
+		Body:   params.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, draftFromDB(dbDraft))
+}
+
+func (cfg *apiConfig) handlerDraftsRetrieve(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbDrafts, err := cfg.db.GetDraftsByUserID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve drafts", err)
+		return
+	}
+
+	drafts := []Draft{}
+	for _, dbDraft := range dbDrafts {
+		drafts = append(drafts, draftFromDB(dbDraft))
+	}
+
+	respondWithJSON(w, http.StatusOK, drafts)
+}
+
+func (cfg *apiConfig) handlerDraftRetrieve(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get draftID from path
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	dbDraft, err := cfg.db.GetDraftByID(r.Context(), database.GetDraftByIDParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDB(dbDraft))
+}
+
+func (cfg *apiConfig) handlerDraftsUpdate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get draftID from path
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if err := cfg.validateDraft(r.Context(), userID, params.Body); err != nil {
+		respondWithDraftError(w, err)
+		return
+	}
+
+	dbDraft, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:     draftID,
+		UserID: userID,
+		Body:   params.Body,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDB(dbDraft))
+}
+
+func (cfg *apiConfig) handlerDraftsDelete(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get draftID from path
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	n, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerDraftsPublish(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get draftID from path
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// lock the draft so two concurrent publishes can't both create a chirp
+	dbDraft, err := qtx.LockDraftByID(r.Context(), database.LockDraftByIDParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+
+	// the plan or moderation rules may have changed since the draft was saved
+	chirp, err := cfg.createChirp(r.Context(), qtx, userID, createChirpParams{Body: dbDraft.Body})
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	if _, err := qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     dbDraft.ID,
+		UserID: userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+
+	chirps := []Chirp{chirp}
+	if err := cfg.decorateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
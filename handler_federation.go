@@ -0,0 +1,361 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bontaramsonta/go-chirpy/internal/activitypub"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	apOutboxLimit   = 20
+	apMaxInboxBytes = 1 << 20
+)
+
+// respondWithActivityJSON is respondWithJSON for ActivityPub and WebFinger
+// documents, which have their own media types.
+func respondWithActivityJSON(w http.ResponseWriter, code int, contentType string, payload any) {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write(dat)
+}
+
+// apUser loads the user with the given ID, responding with 404 for unknown
+// and banned users.
+func (cfg *apiConfig) apUser(w http.ResponseWriter, r *http.Request, rawID string) (database.User, bool) {
+	userID, err := uuid.Parse(rawID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return database.User{}, false
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dbUser.BannedAt.Valid) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return database.User{}, false
+	}
+	return dbUser, true
+}
+
+// handlerWebFinger resolves acct:<user id>@<host> to the user's actor, which
+// is how remote servers look up an account typed in as @<user id>@<host>.
+func (cfg *apiConfig) handlerWebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		respondWithError(w, http.StatusBadRequest, "resource is required", nil)
+		return
+	}
+
+	var name string
+	if actorID, ok := strings.CutPrefix(resource, cfg.baseURL+"/ap/users/"); ok {
+		name = actorID
+	} else {
+		user, host, err := activitypub.ParseAcct(resource)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid resource", err)
+			return
+		}
+		base, _ := url.Parse(cfg.baseURL)
+		if host != strings.ToLower(base.Host) {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		name = user
+	}
+
+	dbUser, ok := cfg.apUser(w, r, name)
+	if !ok {
+		return
+	}
+
+	base, _ := url.Parse(cfg.baseURL)
+	actorID := cfg.apActorURL(dbUser.ID)
+	respondWithActivityJSON(w, http.StatusOK, activitypub.ContentTypeJRD, activitypub.WebFinger{
+		Subject: "acct:" + dbUser.ID.String() + "@" + base.Host,
+		Aliases: []string{actorID},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: actorID},
+		},
+	})
+}
+
+func (cfg *apiConfig) handlerAPActor(w http.ResponseWriter, r *http.Request) {
+	// get userID from path
+	dbUser, ok := cfg.apUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	key, err := cfg.apKey(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve actor key", err)
+		return
+	}
+
+	actorID := cfg.apActorURL(dbUser.ID)
+	actor := activitypub.Actor{
+		Context:           []string{activitypub.ActivityStreamsContext, activitypub.SecurityContext},
+		ID:                actorID,
+		Type:              activitypub.TypePerson,
+		PreferredUsername: dbUser.ID.String(),
+		Name:              dbUser.ID.String(),
+		URL:               cfg.baseURL + "/api/chirps?author_id=" + dbUser.ID.String(),
+		Inbox:             actorID + "/inbox",
+		Outbox:            actorID + "/outbox",
+		Followers:         actorID + "/followers",
+		PublicKey: activitypub.PublicKey{
+			ID:           actorID + apKeyFragment,
+			Owner:        actorID,
+			PublicKeyPem: key.PublicKey,
+		},
+	}
+	if dbUser.AvatarVersion.Valid {
+		actor.Icon = &activitypub.Image{
+			Type:      "Image",
+			MediaType: "image/png",
+			URL:       cfg.baseURL + avatarURL(dbUser.ID, dbUser.AvatarVersion.String),
+		}
+	}
+	respondWithActivityJSON(w, http.StatusOK, activitypub.ContentType, actor)
+}
+
+// handlerAPOutbox lists the user's latest chirps as Create activities.
+func (cfg *apiConfig) handlerAPOutbox(w http.ResponseWriter, r *http.Request) {
+	// get userID from path
+	dbUser, ok := cfg.apUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByAuthorID(r.Context(), database.GetChirpsByAuthorIDParams{
+		UserID: dbUser.ID,
+		Limit:  sql.NullInt32{Int32: apOutboxLimit, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps for author", err)
+		return
+	}
+	total, err := cfg.db.CountChirpsByAuthorID(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count chirps for author", err)
+		return
+	}
+
+	outbox := activitypub.OrderedCollection{
+		Context:      activitypub.ActivityStreamsContext,
+		ID:           cfg.apActorURL(dbUser.ID) + "/outbox",
+		Type:         activitypub.TypeOrderedCollection,
+		TotalItems:   total,
+		OrderedItems: []any{},
+	}
+	for _, dbChirp := range dbChirps {
+		activity, err := cfg.apCreateActivity(dbChirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't build outbox", err)
+			return
+		}
+		outbox.OrderedItems = append(outbox.OrderedItems, activity)
+	}
+	respondWithActivityJSON(w, http.StatusOK, activitypub.ContentType, outbox)
+}
+
+// handlerAPFollowers only publishes the follower count; the followers
+// themselves are not listed.
+func (cfg *apiConfig) handlerAPFollowers(w http.ResponseWriter, r *http.Request) {
+	// get userID from path
+	dbUser, ok := cfg.apUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	count, err := cfg.db.CountAPFollowers(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count followers", err)
+		return
+	}
+	respondWithActivityJSON(w, http.StatusOK, activitypub.ContentType, activitypub.OrderedCollection{
+		Context:    activitypub.ActivityStreamsContext,
+		ID:         cfg.apActorURL(dbUser.ID) + "/followers",
+		Type:       activitypub.TypeOrderedCollection,
+		TotalItems: count,
+	})
+}
+
+// handlerAPNote serves a chirp as a note. Deleted chirps are served as a
+// Tombstone with 410 Gone so remote servers drop their copies.
+func (cfg *apiConfig) handlerAPNote(w http.ResponseWriter, r *http.Request) {
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := cfg.db.GetDeletedChirpByID(r.Context(), int32(id)); err == nil {
+			respondWithActivityJSON(w, http.StatusGone, activitypub.ContentType, activitypub.Note{
+				Context: activitypub.ActivityStreamsContext,
+				ID:      cfg.apNoteURL(int32(id)),
+				Type:    activitypub.TypeTombstone,
+			})
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+	if dbChirp.HiddenAt.Valid || dbChirp.PublishAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+	author, err := cfg.db.GetUserByID(r.Context(), dbChirp.UserID)
+	if err != nil || author.BannedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	note := cfg.apNoteFromChirp(dbChirp)
+	note.Context = activitypub.ActivityStreamsContext
+	respondWithActivityJSON(w, http.StatusOK, activitypub.ContentType, note)
+}
+
+// handlerAPInbox accepts activities from remote servers. Requests must carry
+// an HTTP signature from the activity's actor. Follow and Undo of a Follow
+// are handled; anything else is accepted and ignored.
+func (cfg *apiConfig) handlerAPInbox(w http.ResponseWriter, r *http.Request) {
+	// get userID from path
+	dbUser, ok := cfg.apUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apMaxInboxBytes))
+	if err != nil {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Activity is too large", err)
+		return
+	}
+
+	// the remote actor is fetched as the local user, for servers that
+	// require signed fetches
+	signer, err := cfg.apSigner(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve actor key", err)
+		return
+	}
+	remote, err := cfg.apClient.VerifyRequest(r.Context(), r, body, signer)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid signature", err)
+		return
+	}
+
+	activity := activitypub.Activity{}
+	if err := json.Unmarshal(body, &activity); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid activity", err)
+		return
+	}
+	// servers may only send activities by their own actors
+	if activity.Actor != remote.ID {
+		respondWithError(w, http.StatusUnauthorized, "Activity is not by the signing actor", nil)
+		return
+	}
+
+	actorID := cfg.apActorURL(dbUser.ID)
+	switch activity.Type {
+	case activitypub.TypeFollow:
+		if activity.ObjectID() != actorID {
+			respondWithError(w, http.StatusBadRequest, "Follow is not for this actor", nil)
+			return
+		}
+		cfg.apAcceptFollow(w, r, dbUser.ID, remote, activity)
+		return
+	case activitypub.TypeUndo:
+		follow, err := activity.EmbeddedActivity()
+		if err == nil && follow.Type == activitypub.TypeFollow && follow.Actor == remote.ID && follow.ObjectID() == actorID {
+			if _, err := cfg.db.RemoveAPFollower(r.Context(), database.RemoveAPFollowerParams{
+				UserID:  dbUser.ID,
+				ActorID: remote.ID,
+			}); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't remove follower", err)
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// apAcceptFollow records remote as a follower of userID and queues the
+// Accept, in one transaction.
+func (cfg *apiConfig) apAcceptFollow(w http.ResponseWriter, r *http.Request, userID uuid.UUID, remote activitypub.Actor, follow activitypub.Activity) {
+	actorID := cfg.apActorURL(userID)
+	accept, err := activitypub.NewActivity(actorID+"#accepts/"+uuid.NewString(), activitypub.TypeAccept, actorID, follow, nil, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept follow", err)
+		return
+	}
+	payload, err := json.Marshal(accept)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept follow", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept follow", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	sharedInbox := sql.NullString{}
+	if remote.SharedInbox() != remote.Inbox {
+		sharedInbox = sql.NullString{String: remote.SharedInbox(), Valid: true}
+	}
+	if err := qtx.AddAPFollower(r.Context(), database.AddAPFollowerParams{
+		UserID:      userID,
+		ActorID:     remote.ID,
+		Inbox:       remote.Inbox,
+		SharedInbox: sharedInbox,
+		FollowID:    follow.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add follower", err)
+		return
+	}
+	if err := qtx.EnqueueAPDelivery(r.Context(), database.EnqueueAPDeliveryParams{
+		UserID:  userID,
+		Inbox:   remote.Inbox,
+		Payload: payload,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept follow", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't accept follow", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/feeds"
+	"github.com/google/uuid"
+)
+
+const (
+	feedEntriesLimit = 50
+	feedCacheControl = "public, max-age=60"
+
+	feedFormatAtom = "atom"
+	feedFormatRSS  = "rss"
+)
+
+func (cfg *apiConfig) handlerChirpsFeedAtom(w http.ResponseWriter, r *http.Request) {
+	cfg.serveChirpsFeed(w, r, feedFormatAtom)
+}
+
+func (cfg *apiConfig) handlerChirpsFeedRSS(w http.ResponseWriter, r *http.Request) {
+	cfg.serveChirpsFeed(w, r, feedFormatRSS)
+}
+
+func (cfg *apiConfig) handlerUsersFeedAtom(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, feedFormatAtom)
+}
+
+func (cfg *apiConfig) handlerUsersFeedRSS(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, feedFormatRSS)
+}
+
+func (cfg *apiConfig) serveChirpsFeed(w http.ResponseWriter, r *http.Request, format string) {
+	// feed readers are anonymous, so feeds show what anyone can see
+	dbChirps, err := cfg.db.GetAllChirps(r.Context(), database.GetAllChirpsParams{
+		Limit: sql.NullInt32{Int32: feedEntriesLimit, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	feed := cfg.chirpsFeed(dbChirps, time.Unix(0, 0))
+	feed.Title = "Chirpy"
+	feed.Description = "Latest chirps"
+	feed.Link = cfg.baseURL + "/api/chirps"
+	feed.ID = cfg.baseURL + "/api/chirps/feed.atom"
+	feed.SelfLink = cfg.baseURL + "/api/chirps/feed." + format
+	serveFeed(w, r, feed, format)
+}
+
+func (cfg *apiConfig) serveUserFeed(w http.ResponseWriter, r *http.Request, format string) {
+	// get userID from path
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dbUser.BannedAt.Valid) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByAuthorID(r.Context(), database.GetChirpsByAuthorIDParams{
+		UserID: userID,
+		Limit:  sql.NullInt32{Int32: feedEntriesLimit, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps for author", err)
+		return
+	}
+
+	feed := cfg.chirpsFeed(dbChirps, dbUser.CreatedAt)
+	feed.Title = fmt.Sprintf("Chirps by %s", userID)
+	feed.Link = fmt.Sprintf("%s/api/chirps?author_id=%s", cfg.baseURL, userID)
+	feed.ID = fmt.Sprintf("%s/api/users/%s/feed.atom", cfg.baseURL, userID)
+	feed.SelfLink = fmt.Sprintf("%s/api/users/%s/feed.%s", cfg.baseURL, userID, format)
+	serveFeed(w, r, feed, format)
+}
+
+// chirpsFeed turns the newest chirps, newest first, into feed entries. An
+// empty feed is dated empty.
+func (cfg *apiConfig) chirpsFeed(dbChirps []database.Chirp, empty time.Time) feeds.Feed {
+	feed := feeds.Feed{Updated: empty}
+	for i, dbChirp := range dbChirps {
+		chirp := chirpFromDB(dbChirp)
+		// chirp URLs never change, so they double as entry IDs
+		link := fmt.Sprintf("%s/api/chirps/%d", cfg.baseURL, chirp.ID)
+		feed.Entries = append(feed.Entries, feeds.Entry{
+			ID:         link,
+			Link:       link,
+			AuthorName: chirp.UserID.String(),
+			AuthorURI:  fmt.Sprintf("%s/api/users/%s/feed.atom", cfg.baseURL, chirp.UserID),
+			Content:    chirp.Body,
+			Published:  chirp.CreatedAt,
+			Updated:    chirp.UpdatedAt,
+		})
+		if i == 0 || chirp.UpdatedAt.After(feed.Updated) {
+			feed.Updated = chirp.UpdatedAt
+		}
+	}
+	return feed
+}
+
+// serveFeed renders feed and serves it with an ETag and Last-Modified so
+// readers can poll with conditional requests. The ETag covers the whole
+// document, so it also changes when a chirp is deleted.
+func serveFeed(w http.ResponseWriter, r *http.Request, feed feeds.Feed, format string) {
+	var data []byte
+	var err error
+	contentType := feeds.ContentTypeAtom
+	if format == feedFormatRSS {
+		data, err = feed.RSS()
+		contentType = feeds.ContentTypeRSS
+	} else {
+		data, err = feed.Atom()
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build feed", err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", feedCacheControl)
+	w.Header().Set("Content-Type", contentType)
+	// ServeContent answers If-None-Match and If-Modified-Since with 304
+	http.ServeContent(w, r, "", feed.Updated, bytes.NewReader(data))
+}
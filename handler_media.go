@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/entitlements"
+	"github.com/bontaramsonta/go-chirpy/internal/media"
+	"github.com/bontaramsonta/go-chirpy/internal/storage"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerMediaUpload(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// check plan limits
+	limits, err := cfg.userLimits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load plan limits", err)
+		return
+	}
+	// turn away users already at their limits before reading the upload; the
+	// check that counts is repeated under a lock when the upload is recorded
+	usage, err := cfg.db.GetMediaUsage(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check media limits", err)
+		return
+	}
+	if !checkMediaUsage(w, limits, usage, 0) {
+		return
+	}
+
+	data, ok := readImageUpload(w, r, maxMediaUploadBytes)
+	if !ok {
+		return
+	}
+
+	// the client's Content-Type is ignored; re-encoding also strips EXIF
+	original, thumbnail, err := media.Process(data, mediaThumbnailSize)
+	if err != nil {
+		respondWithImageError(w, err)
+		return
+	}
+	id := uuid.New()
+	ext := "." + strings.TrimPrefix(original.ContentType, "image/")
+	key := "media/" + id.String() + ext
+	thumbnailKey := "media/" + id.String() + "_thumb" + ext
+
+	if err := cfg.blobs.Put(r.Context(), key, original.ContentType, original.Data); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store image", err)
+		return
+	}
+	if err := cfg.blobs.Put(r.Context(), thumbnailKey, thumbnail.ContentType, thumbnail.Data); err != nil {
+		cfg.deleteBlobs(r, key)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store image", err)
+		return
+	}
+
+	dbMedia, ok := cfg.recordMediaUpload(w, r, limits, database.CreateMediaParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  original.ContentType,
+		Width:        int32(original.Width),
+		Height:       int32(original.Height),
+		SizeBytes:    int32(len(original.Data)),
+		StorageKey:   key,
+		ThumbnailKey: thumbnailKey,
+	})
+	if !ok {
+		cfg.deleteBlobs(r, key, thumbnailKey)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mediaFromDB(dbMedia))
+}
+
+// recordMediaUpload checks the user's media limits and inserts the upload in
+// one transaction, holding a lock on the user so concurrent uploads can't
+// both fit under the quota. It responds with an error and returns false if
+// that fails.
+func (cfg *apiConfig) recordMediaUpload(w http.ResponseWriter, r *http.Request, limits entitlements.Limits, params database.CreateMediaParams) (database.Medium, bool) {
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store image", err)
+		return database.Medium{}, false
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if err := qtx.LockUserMediaUsage(r.Context(), params.UserID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check media limits", err)
+		return database.Medium{}, false
+	}
+	usage, err := qtx.GetMediaUsage(r.Context(), params.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check media limits", err)
+		return database.Medium{}, false
+	}
+	if !checkMediaUsage(w, limits, usage, int64(params.SizeBytes)) {
+		return database.Medium{}, false
+	}
+
+	dbMedia, err := qtx.CreateMedia(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store image", err)
+		return database.Medium{}, false
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store image", err)
+		return database.Medium{}, false
+	}
+	return dbMedia, true
+}
+
+// checkMediaUsage reports whether an upload of size bytes fits the plan's
+// media limits, responding with an error if it doesn't.
+func checkMediaUsage(w http.ResponseWriter, limits entitlements.Limits, usage database.GetMediaUsageRow, size int64) bool {
+	if limits.MediaUploadsPerHour > 0 && usage.RecentUploads >= int64(limits.MediaUploadsPerHour) {
+		respondWithError(w, http.StatusTooManyRequests, "Media upload rate limit exceeded", nil)
+		return false
+	}
+	if limits.MediaStorageBytes > 0 && usage.StorageBytes+size > limits.MediaStorageBytes {
+		respondWithError(w, http.StatusForbidden, "Media storage quota exceeded", nil)
+		return false
+	}
+	return true
+}
+
+// readImageUpload reads the "file" field of a multipart upload of at most
+// maxBytes. It responds with an error and returns false if that fails.
+func readImageUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, bool) {
+	// leave some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Image is too large", err)
+			return nil, false
+		}
+		respondWithError(w, http.StatusBadRequest, "Expected an image in the \"file\" form field", err)
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read image", err)
+		return nil, false
+	}
+	if int64(len(data)) > maxBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Image is too large", nil)
+		return nil, false
+	}
+	return data, true
+}
+
+func respondWithImageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only PNG and JPEG images are supported", err)
+	case errors.Is(err, media.ErrTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Image dimensions are too large", err)
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid image", err)
+	}
+}
+
+// deleteBlobs cleans up after a failed upload. Failures are only logged since
+// the request is already failing.
+func (cfg *apiConfig) deleteBlobs(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if err := cfg.blobs.Delete(r.Context(), key); err != nil {
+			log.Printf("Couldn't delete blob %s: %s", key, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerMediaGet(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerMediaThumbnailGet(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	// get mediaID from path
+	id, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid media ID", err)
+		return
+	}
+
+	dbMedia, err := cfg.db.GetMediaByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve media", err)
+		return
+	}
+
+	key := dbMedia.StorageKey
+	etag := `"` + dbMedia.ID.String() + `"`
+	if thumbnail {
+		key = dbMedia.ThumbnailKey
+		etag = `"` + dbMedia.ID.String() + `-thumb"`
+	}
+
+	// stored media never changes, so its id is a stable validator
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", mediaCacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.blobs.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve media", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", mediaCacheControl)
+	w.Header().Set("Content-Type", dbMedia.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
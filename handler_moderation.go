@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	ModActionHideChirp       = "hide_chirp"
+	ModActionRestoreChirp    = "restore_chirp"
+	ModActionDismissReport   = "dismiss_report"
+	ModActionSuspendUser     = "suspend_user"
+	ModActionBanUser         = "ban_user"
+	ModActionReinstateUser   = "reinstate_user"
+	ModActionGrantModerator  = "grant_moderator"
+	ModActionRevokeModerator = "revoke_moderator"
+
+	ModTargetChirp  = "chirp"
+	ModTargetReport = "report"
+	ModTargetUser   = "user"
+
+	defaultModerationActionsLimit = 50
+	maxModerationActionsLimit     = 200
+)
+
+type ReportQueueItem struct {
+	Report
+	Chirp Chirp `json:"chirp"`
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID  `json:"id"`
+	ModeratorID *uuid.UUID `json:"moderator_id"`
+	Action      string     `json:"action"`
+	TargetType  string     `json:"target_type"`
+	TargetID    string     `json:"target_id"`
+	Reason      string     `json:"reason"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// recordModerationAction writes an audit entry. moderatorID is nil for
+// actions taken with the admin API key.
+func recordModerationAction(ctx context.Context, q *database.Queries, moderatorID *uuid.UUID, action, targetType, targetID, reason string) error {
+	dbModeratorID := uuid.NullUUID{}
+	if moderatorID != nil {
+		dbModeratorID = uuid.NullUUID{UUID: *moderatorID, Valid: true}
+	}
+	_, err := q.RecordModerationAction(ctx, database.RecordModerationActionParams{
+		ModeratorID: dbModeratorID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      reason,
+	})
+	return err
+}
+
+// decodeModerationReason reads the optional reason from the request body. An
+// empty body, chunked or not, means no reason.
+func decodeModerationReason(r *http.Request) (string, error) {
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+	params := parameters{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if errors.Is(err, io.EOF) {
+		return "", nil
+	}
+	return params.Reason, err
+}
+
+func (cfg *apiConfig) handlerModerationQueue(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.db.ListOpenReports(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve reports", err)
+		return
+	}
+
+	queue := []ReportQueueItem{}
+	for _, row := range rows {
+		queue = append(queue, ReportQueueItem{
+			Report: reportFromDB(row.Report),
+			Chirp:  chirpFromDB(row.Chirp),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, queue)
+}
+
+func (cfg *apiConfig) handlerModerationChirpHide(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpHidden(w, r, true)
+}
+
+func (cfg *apiConfig) handlerModerationChirpRestore(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpHidden(w, r, false)
+}
+
+func (cfg *apiConfig) setChirpHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	// get moderator userID from context
+	moderatorID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	reason, err := decodeModerationReason(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	var dbChirp database.Chirp
+	action := ModActionRestoreChirp
+	if hidden {
+		action = ModActionHideChirp
+		dbChirp, err = qtx.HideChirp(r.Context(), int32(id))
+	} else {
+		dbChirp, err = qtx.RestoreChirp(r.Context(), int32(id))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	// hiding a chirp settles every open report against it
+	if hidden {
+		if _, err := qtx.ResolveReportsForChirp(r.Context(), database.ResolveReportsForChirpParams{
+			ChirpID:    dbChirp.ID,
+			Status:     ReportStatusActioned,
+			ResolvedBy: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't resolve reports", err)
+			return
+		}
+	}
+
+	if err := recordModerationAction(r.Context(), qtx, &moderatorID, action, ModTargetChirp, strconv.Itoa(int(dbChirp.ID)), reason); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerModerationReportDismiss(w http.ResponseWriter, r *http.Request) {
+	// get moderator userID from context
+	moderatorID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+	reason, err := decodeModerationReason(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't dismiss report", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	dbReport, err := qtx.ResolveReport(r.Context(), database.ResolveReportParams{
+		ID:         id,
+		Status:     ReportStatusDismissed,
+		ResolvedBy: uuid.NullUUID{UUID: moderatorID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Open report not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't dismiss report", err)
+		return
+	}
+
+	if err := recordModerationAction(r.Context(), qtx, &moderatorID, ModActionDismissReport, ModTargetReport, dbReport.ID.String(), reason); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't dismiss report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, reportFromDB(dbReport))
+}
+
+func (cfg *apiConfig) handlerModerationUserSuspend(w http.ResponseWriter, r *http.Request) {
+	// get moderator userID from context
+	moderatorID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		Until  time.Time `json:"until"`
+		Reason string    `json:"reason"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	// validations
+	if !params.Until.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "Suspension must end in the future", nil)
+		return
+	}
+	if id == moderatorID {
+		respondWithError(w, http.StatusBadRequest, "You can't suspend yourself", nil)
+		return
+	}
+
+	cfg.moderateUser(w, r, moderatorID, id, ModActionSuspendUser, params.Reason, func(qtx *database.Queries) error {
+		_, err := qtx.SuspendUser(r.Context(), database.SuspendUserParams{
+			ID:             id,
+			SuspendedUntil: sql.NullTime{Time: params.Until.UTC(), Valid: true},
+		})
+		return err
+	})
+}
+
+func (cfg *apiConfig) handlerModerationUserBan(w http.ResponseWriter, r *http.Request) {
+	// get moderator userID from context
+	moderatorID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	if id == moderatorID {
+		respondWithError(w, http.StatusBadRequest, "You can't ban yourself", nil)
+		return
+	}
+	reason, err := decodeModerationReason(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cfg.moderateUser(w, r, moderatorID, id, ModActionBanUser, reason, func(qtx *database.Queries) error {
+		_, err := qtx.BanUser(r.Context(), id)
+		return err
+	})
+}
+
+func (cfg *apiConfig) handlerModerationUserReinstate(w http.ResponseWriter, r *http.Request) {
+	// get moderator userID from context
+	moderatorID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	reason, err := decodeModerationReason(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	cfg.moderateUser(w, r, moderatorID, id, ModActionReinstateUser, reason, func(qtx *database.Queries) error {
+		_, err := qtx.ReinstateUser(r.Context(), id)
+		return err
+	})
+}
+
+// moderateUser applies update to the target user and records the action in
+// one transaction, then drops the user's cached status so the change reaches
+// their access tokens straight away on this instance.
+func (cfg *apiConfig) moderateUser(w http.ResponseWriter, r *http.Request, moderatorID, userID uuid.UUID, action, reason string, update func(qtx *database.Queries) error) {
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = update(qtx)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	if err := recordModerationAction(r.Context(), qtx, &moderatorID, action, ModTargetUser, userID.String(), reason); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	cfg.userStatuses.invalidate(userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerModerationActionsList(w http.ResponseWriter, r *http.Request) {
+	limit := defaultModerationActionsLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxModerationActionsLimit)
+	}
+
+	dbActions, err := cfg.db.ListModerationActions(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve moderation actions", err)
+		return
+	}
+
+	actions := []ModerationAction{}
+	for _, dbAction := range dbActions {
+		action := ModerationAction{
+			ID:         dbAction.ID,
+			Action:     dbAction.Action,
+			TargetType: dbAction.TargetType,
+			TargetID:   dbAction.TargetID,
+			Reason:     dbAction.Reason,
+			CreatedAt:  dbAction.CreatedAt,
+		}
+		if dbAction.ModeratorID.Valid {
+			action.ModeratorID = &dbAction.ModeratorID.UUID
+		}
+		actions = append(actions, action)
+	}
+
+	respondWithJSON(w, http.StatusOK, actions)
+}
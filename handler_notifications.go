@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerNotificationsRetrieve(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	limit := defaultNotificationsLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxNotificationsLimit)
+	}
+
+	// before and before_id are the updated_at and id of the last
+	// notification on the previous page
+	before := sql.NullTime{}
+	if b := r.URL.Query().Get("before"); b != "" {
+		t, err := time.Parse(time.RFC3339Nano, b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before timestamp", err)
+			return
+		}
+		before = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	beforeID := uuid.NullUUID{}
+	if b := r.URL.Query().Get("before_id"); b != "" {
+		id, err := uuid.Parse(b)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before_id", err)
+			return
+		}
+		if !before.Valid {
+			respondWithError(w, http.StatusBadRequest, "before_id requires before", nil)
+			return
+		}
+		beforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	dbNotifications, err := cfg.db.GetNotificationsByRecipient(r.Context(), database.GetNotificationsByRecipientParams{
+		RecipientID: userID,
+		Before:      before,
+		BeforeID:    beforeID,
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+		return
+	}
+
+	notifications := []Notification{}
+	for _, dbNotification := range dbNotifications {
+		notifications = append(notifications, notificationFromDB(dbNotification))
+	}
+
+	respondWithJSON(w, http.StatusOK, notifications)
+}
+
+func (cfg *apiConfig) handlerNotificationsUnreadCount(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	count, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notifications", err)
+		return
+	}
+
+	type response struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	respondWithJSON(w, http.StatusOK, response{UnreadCount: count})
+}
+
+func (cfg *apiConfig) handlerNotificationsMarkRead(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get notificationID from path
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	n, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:          notificationID,
+		RecipientID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notification read", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Notification not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerNotificationsMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	if err := cfg.db.MarkAllNotificationsRead(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notifications read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
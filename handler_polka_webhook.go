@@ -1,19 +1,60 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
 const (
-	EventUserUpgraded = "user.upgraded"
+	EventUserUpgraded      = "user.upgraded"
+	EventUserRenewed       = "user.renewed"
+	EventUserDowngraded    = "user.downgraded"
+	EventUserCancelled     = "user.cancelled"
+	EventUserPaymentFailed = "user.payment_failed"
 )
 
+const (
+	WebhookProviderPolka = "polka"
+
+	WebhookStatusPending    = "pending"
+	WebhookStatusProcessing = "processing"
+	WebhookStatusProcessed  = "processed"
+	WebhookStatusIgnored    = "ignored"
+	WebhookStatusFailed     = "failed"
+
+	// webhookEventLease is how long a claim on an event lasts; after that the
+	// event counts as abandoned and can be claimed again
+	webhookEventLease = 5 * time.Minute
+)
+
+var (
+	errWebhookEventNotClaimable   = errors.New("webhook event is already processed or in progress")
+	errWebhookUnknownUser         = errors.New("webhook references an unknown user")
+	errWebhookUnknownSubscription = errors.New("webhook references no active subscription")
+)
+
+type polkaEvent struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  struct {
+		UserID           uuid.UUID  `json:"user_id"`
+		Plan             string     `json:"plan"`
+		CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	} `json:"data"`
+}
+
 func (cfg *apiConfig) handlePolkaWebhook(w http.ResponseWriter, r *http.Request) {
 	// check API key
 	apiKey, err := auth.GetAPIKey(r.Header)
@@ -27,31 +68,152 @@ func (cfg *apiConfig) handlePolkaWebhook(w http.ResponseWriter, r *http.Request)
 	}
 
 	// parse body
-	type parameters struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
-		} `json:"data"`
-	}
-	params := parameters{}
-	err = json.NewDecoder(r.Body).Decode(&params)
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	params := polkaEvent{}
+	err = json.Unmarshal(payload, &params)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
+	// polka retries deliveries, so the event id is the dedupe key. deliveries
+	// without an id fall back to a hash of the payload.
+	eventID := params.ID
+	if eventID == "" {
+		sum := sha256.Sum256(payload)
+		eventID = hex.EncodeToString(sum[:])
+	}
+
+	// persist the event, or load the existing row for a redelivery
+	event, err := cfg.db.CreateWebhookEvent(r.Context(), database.CreateWebhookEventParams{
+		Provider:  WebhookProviderPolka,
+		EventID:   eventID,
+		EventType: params.Event,
+		Payload:   payload,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		event, err = cfg.db.GetWebhookEventByProviderID(r.Context(), database.GetWebhookEventByProviderIDParams{
+			Provider: WebhookProviderPolka,
+			EventID:  eventID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to record webhook event", err)
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), event)
+	switch {
+	case errors.Is(err, errWebhookEventNotClaimable):
+		log.Printf("polka webhook duplicate event %s", eventID)
+	case errors.Is(err, errWebhookUnknownUser):
+		log.Print("polka webhook invalid userid")
+	case errors.Is(err, errWebhookUnknownSubscription):
+		log.Print("polka webhook user has no active subscription")
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Failed to process webhook event", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// processWebhookEvent claims a pending, failed or abandoned event, applies it
+// and records the outcome on the event row.
+func (cfg *apiConfig) processWebhookEvent(ctx context.Context, event database.WebhookEvent) error {
+	claimed, err := cfg.db.ClaimWebhookEvent(ctx, database.ClaimWebhookEventParams{
+		ID:           event.ID,
+		LeaseSeconds: int32(webhookEventLease.Seconds()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errWebhookEventNotClaimable
+	}
+	if err != nil {
+		return err
+	}
+
+	status, procErr := cfg.applyPolkaEvent(ctx, claimed.Payload)
+	errMsg := sql.NullString{}
+	if procErr != nil {
+		status = WebhookStatusFailed
+		errMsg = sql.NullString{String: procErr.Error(), Valid: true}
+	}
+
+	err = cfg.db.FinishWebhookEvent(ctx, database.FinishWebhookEventParams{
+		ID:       claimed.ID,
+		Status:   status,
+		Error:    errMsg,
+		Attempts: claimed.Attempts,
+	})
+	if err != nil {
+		return err
+	}
+	return procErr
+}
+
+func (cfg *apiConfig) applyPolkaEvent(ctx context.Context, payload json.RawMessage) (string, error) {
+	params := polkaEvent{}
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return "", err
+	}
+
+	userID := params.Data.UserID
+	var err error
 	switch params.Event {
-	case EventUserUpgraded:
-		_, err := cfg.db.UpgradeUser(r.Context(), params.Data.UserID)
-		if err != nil {
-			if err.Error() == "sql: no rows in result set" {
-				log.Print("polka webhook invalid userid")
-				break
-			}
-			respondWithError(w, http.StatusInternalServerError, "Failed to upgrade user", err)
-			return
+	case EventUserUpgraded, EventUserRenewed:
+		err = cfg.applyPolkaActivation(ctx, params)
+	case EventUserDowngraded:
+		err = cfg.endSubscription(ctx, userID)
+	case EventUserCancelled:
+		// the user keeps Chirpy Red until the end of the paid period
+		_, err = cfg.db.CancelSubscription(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errWebhookUnknownSubscription
+		}
+	case EventUserPaymentFailed:
+		_, err = cfg.db.MarkSubscriptionPastDue(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errWebhookUnknownSubscription
 		}
+	default:
+		return WebhookStatusIgnored, nil
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errWebhookUnknownUser
+	}
+	if err != nil {
+		return "", err
+	}
+	return WebhookStatusProcessed, nil
+}
+
+// applyPolkaActivation starts or renews a subscription. Renewals extend the
+// current period unless Polka tells us when the new period ends.
+func (cfg *apiConfig) applyPolkaActivation(ctx context.Context, params polkaEvent) error {
+	plan := params.Data.Plan
+	if plan == "" {
+		plan = PlanChirpyRed
+	}
+
+	periodStart := time.Now()
+	if params.Event == EventUserRenewed {
+		sub, err := cfg.db.GetSubscriptionByUserID(ctx, params.Data.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && sub.CurrentPeriodEnd.Valid && sub.CurrentPeriodEnd.Time.After(periodStart) {
+			periodStart = sub.CurrentPeriodEnd.Time
+		}
+	}
+	periodEnd := periodStart.Add(subscriptionPeriod)
+	if params.Data.CurrentPeriodEnd != nil {
+		periodEnd = *params.Data.CurrentPeriodEnd
+	}
+
+	return cfg.activateSubscription(ctx, params.Data.UserID, plan, periodEnd)
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusActioned  = "actioned"
+
+	maxReportReasonLength = 500
+)
+
+type Report struct {
+	ID         uuid.UUID  `json:"id"`
+	ChirpID    int32      `json:"chirp_id"`
+	ReporterID uuid.UUID  `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ResolvedBy *uuid.UUID `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func reportFromDB(dbReport database.Report) Report {
+	report := Report{
+		ID:         dbReport.ID,
+		ChirpID:    dbReport.ChirpID,
+		ReporterID: dbReport.ReporterID,
+		Reason:     dbReport.Reason,
+		Status:     dbReport.Status,
+		CreatedAt:  dbReport.CreatedAt,
+	}
+	if dbReport.ResolvedBy.Valid {
+		report.ResolvedBy = &dbReport.ResolvedBy.UUID
+	}
+	if dbReport.ResolvedAt.Valid {
+		report.ResolvedAt = &dbReport.ResolvedAt.Time
+	}
+	return report
+}
+
+func (cfg *apiConfig) handlerChirpsReport(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// get chirpID from path
+	id, err := strconv.Atoi(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	// parse body
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	// validations
+	params.Reason = strings.TrimSpace(params.Reason)
+	if params.Reason == "" {
+		respondWithError(w, http.StatusBadRequest, "Reason is required", nil)
+		return
+	}
+	if len(params.Reason) > maxReportReasonLength {
+		respondWithError(w, http.StatusBadRequest, "Reason is too long", nil)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), int32(id))
+	if err != nil {
+		cfg.respondChirpNotFound(w, r, int32(id), err)
+		return
+	}
+	if dbChirp.HiddenAt.Valid || dbChirp.PublishAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	dbReport, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ChirpID:    dbChirp.ID,
+		ReporterID: userID,
+		Reason:     params.Reason,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "You have already reported this chirp", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, reportFromDB(dbReport))
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/media"
+	"github.com/bontaramsonta/go-chirpy/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAvatarUploadBytes = 2 << 20
+	avatarMinDimension   = 128
+	defaultAvatarSize    = 128
+
+	// versioned avatar URLs never change content, unversioned ones follow
+	// the user's current avatar
+	avatarCacheControl            = "public, max-age=31536000, immutable"
+	avatarUnversionedCacheControl = "public, max-age=300"
+)
+
+// avatarSizes are the square sizes every avatar is rendered at.
+var avatarSizes = []int{64, 128, 256}
+
+// avatarURL is the cacheable URL for a user's avatar. The version changes
+// whenever a new avatar is uploaded, so clients can cache it indefinitely.
+func avatarURL(userID uuid.UUID, version string) string {
+	return fmt.Sprintf("/api/users/%s/avatar?v=%s", userID, version)
+}
+
+func avatarKey(userID uuid.UUID, version string, size int) string {
+	return fmt.Sprintf("avatars/%s/%s/%d.png", userID, version, size)
+}
+
+func (cfg *apiConfig) handlerUsersAvatarUpdate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	data, ok := readImageUpload(w, r, maxAvatarUploadBytes)
+	if !ok {
+		return
+	}
+	img, _, err := media.Decode(data)
+	if err != nil {
+		respondWithImageError(w, err)
+		return
+	}
+	if bounds := img.Bounds(); bounds.Dx() < avatarMinDimension || bounds.Dy() < avatarMinDimension {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Avatar must be at least %dx%d pixels", avatarMinDimension, avatarMinDimension), nil)
+		return
+	}
+
+	// every upload gets its own version, so its blobs are never shared with
+	// another upload that might be replacing it concurrently
+	version, err := newAvatarVersion()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't process avatar", err)
+		return
+	}
+
+	keys := []string{}
+	for _, size := range avatarSizes {
+		encoded, err := media.Encode(media.Square(img, size), media.ContentTypePNG)
+		if err != nil {
+			cfg.deleteBlobs(r, keys...)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't process avatar", err)
+			return
+		}
+		key := avatarKey(userID, version, size)
+		if err := cfg.blobs.Put(r.Context(), key, encoded.ContentType, encoded.Data); err != nil {
+			cfg.deleteBlobs(r, keys...)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't store avatar", err)
+			return
+		}
+		keys = append(keys, key)
+	}
+
+	user, previous, err := cfg.setUserAvatar(r.Context(), userID, version)
+	if err != nil {
+		cfg.deleteBlobs(r, keys...)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update avatar", err)
+		return
+	}
+
+	// the previous version was current until this commit, and versions are
+	// never reused, so nothing can still be using its blobs
+	if previous.Valid {
+		for _, size := range avatarSizes {
+			cfg.deleteBlobs(r, avatarKey(userID, previous.String, size))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
+
+// setUserAvatar makes version the user's avatar and returns the updated user
+// and the version it replaced, read under a row lock in the same transaction.
+func (cfg *apiConfig) setUserAvatar(ctx context.Context, userID uuid.UUID, version string) (User, sql.NullString, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, sql.NullString{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	previous, err := qtx.GetUserAvatarVersionForUpdate(ctx, userID)
+	if err != nil {
+		return User{}, sql.NullString{}, err
+	}
+	dbUser, err := qtx.SetUserAvatar(ctx, database.SetUserAvatarParams{
+		ID:            userID,
+		AvatarVersion: sql.NullString{String: version, Valid: true},
+	})
+	if err != nil {
+		return User{}, sql.NullString{}, err
+	}
+	user := userFromDB(dbUser)
+
+	// user events carry the email address, so only the user's own endpoints get them
+	if err := enqueueWebhookEvent(ctx, qtx, WebhookEventUserUpdated, uuid.NullUUID{UUID: userID, Valid: true}, user); err != nil {
+		return User{}, sql.NullString{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return User{}, sql.NullString{}, err
+	}
+	return user, previous, nil
+}
+
+func newAvatarVersion() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (cfg *apiConfig) handlerUsersAvatarGet(w http.ResponseWriter, r *http.Request) {
+	// get userID from path
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	size := defaultAvatarSize
+	if s := r.URL.Query().Get("size"); s != "" {
+		size, err = strconv.Atoi(s)
+		if err != nil || !slices.Contains(avatarSizes, size) {
+			respondWithError(w, http.StatusBadRequest, "Invalid avatar size", err)
+			return
+		}
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve avatar", err)
+		return
+	}
+	if err != nil || !dbUser.AvatarVersion.Valid {
+		respondWithError(w, http.StatusNotFound, "Avatar not found", err)
+		return
+	}
+	version := dbUser.AvatarVersion.String
+
+	etag := fmt.Sprintf(`"%s-%d"`, version, size)
+	cacheControl := avatarUnversionedCacheControl
+	if r.URL.Query().Get("v") == version {
+		cacheControl = avatarCacheControl
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", cacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.blobs.Get(r.Context(), avatarKey(userID, version, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Avatar not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve avatar", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Content-Type", media.ContentTypePNG)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type DMSettings struct {
+	AllowDMsFrom string `json:"allow_dms_from"`
+	FilterDMs    bool   `json:"filter_dms"`
+}
+
+func dmSettingsFromDB(dbUser database.User) DMSettings {
+	return DMSettings{
+		AllowDMsFrom: dbUser.DmPolicy,
+		FilterDMs:    dbUser.DmFilter,
+	}
+}
+
+func (cfg *apiConfig) handlerUsersDMSettingsGet(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve settings", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dmSettingsFromDB(dbUser))
+}
+
+func (cfg *apiConfig) handlerUsersDMSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// omitted fields keep their current value
+	type parameters struct {
+		AllowDMsFrom *string `json:"allow_dms_from"`
+		FilterDMs    *bool   `json:"filter_dms"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update settings", err)
+		return
+	}
+	settings := dmSettingsFromDB(dbUser)
+	if params.AllowDMsFrom != nil {
+		if *params.AllowDMsFrom != DMPolicyEveryone && *params.AllowDMsFrom != DMPolicyNobody {
+			respondWithError(w, http.StatusBadRequest, "allow_dms_from must be \"everyone\" or \"nobody\"", nil)
+			return
+		}
+		settings.AllowDMsFrom = *params.AllowDMsFrom
+	}
+	if params.FilterDMs != nil {
+		settings.FilterDMs = *params.FilterDMs
+	}
+
+	dbUser, err = cfg.db.UpdateUserDMSettings(r.Context(), database.UpdateUserDMSettingsParams{
+		ID:       userID,
+		DmPolicy: settings.AllowDMsFrom,
+		DmFilter: settings.FilterDMs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update settings", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dmSettingsFromDB(dbUser))
+}
@@ -50,6 +50,11 @@ func (cfg *apiConfig) handlerUsersLogin(w http.ResponseWriter, r *http.Request)
 		authenticationErrResponse(err)
 		return
 	}
+	status := userStatus{banned: user.BannedAt.Valid, suspendedUntil: user.SuspendedUntil}
+	if restriction := status.restriction(time.Now()); restriction != "" {
+		respondWithError(w, http.StatusForbidden, restriction, nil)
+		return
+	}
 
 	// generate token
 	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret)
@@ -85,13 +90,7 @@ func (cfg *apiConfig) handlerUsersLogin(w http.ResponseWriter, r *http.Request)
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User:         userFromDB(user),
 		Token:        token,
 		RefreshToken: refreshToken,
 	})
@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/bontaramsonta/go-chirpy/internal/auth"
 	"github.com/google/uuid"
@@ -11,6 +12,17 @@ func (cfg *apiConfig) handlerUsersRefresh(w http.ResponseWriter, r *http.Request
 	// get userID from context
 	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
 
+	// suspended and banned users can't mint new access tokens
+	status, err := cfg.userStatus(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	if restriction := status.restriction(time.Now()); restriction != "" {
+		respondWithError(w, http.StatusForbidden, restriction, nil)
+		return
+	}
+
 	// generate access token
 	accessToken, err := auth.MakeJWT(userID, cfg.jwtSecret)
 	if err != nil {
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	Plan             string     `json:"plan"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	CancelledAt      *time.Time `json:"cancelled_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (cfg *apiConfig) handlerUsersSubscription(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbSubscription, err := cfg.db.GetSubscriptionByUserID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Subscription not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve subscription", err)
+		return
+	}
+
+	subscription := Subscription{
+		Plan:      dbSubscription.Plan,
+		Status:    dbSubscription.Status,
+		CreatedAt: dbSubscription.CreatedAt,
+		UpdatedAt: dbSubscription.UpdatedAt,
+	}
+	if dbSubscription.CurrentPeriodEnd.Valid {
+		subscription.CurrentPeriodEnd = &dbSubscription.CurrentPeriodEnd.Time
+	}
+	if dbSubscription.CancelledAt.Valid {
+		subscription.CancelledAt = &dbSubscription.CancelledAt.Time
+	}
+
+	respondWithJSON(w, http.StatusOK, subscription)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 200
+)
+
+type WebhookEndpoint struct {
+	ID           uuid.UUID  `json:"id"`
+	URL          string     `json:"url"`
+	Events       []string   `json:"events"`
+	Enabled      bool       `json:"enabled"`
+	FailureCount int32      `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func webhookEndpointFromDB(dbEndpoint database.WebhookEndpoint) WebhookEndpoint {
+	endpoint := WebhookEndpoint{
+		ID:           dbEndpoint.ID,
+		URL:          dbEndpoint.Url,
+		Events:       dbEndpoint.Events,
+		Enabled:      dbEndpoint.Enabled,
+		FailureCount: dbEndpoint.FailureCount,
+		CreatedAt:    dbEndpoint.CreatedAt,
+		UpdatedAt:    dbEndpoint.UpdatedAt,
+	}
+	if dbEndpoint.DisabledAt.Valid {
+		endpoint.DisabledAt = &dbEndpoint.DisabledAt.Time
+	}
+	return endpoint
+}
+
+func webhookDeliveryFromDB(dbDelivery database.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:            dbDelivery.ID,
+		EventType:     dbDelivery.EventType,
+		Payload:       dbDelivery.Payload,
+		Status:        dbDelivery.Status,
+		Attempts:      dbDelivery.Attempts,
+		NextAttemptAt: dbDelivery.NextAttemptAt,
+		LastError:     dbDelivery.LastError.String,
+		CreatedAt:     dbDelivery.CreatedAt,
+	}
+	if dbDelivery.LastStatusCode.Valid {
+		delivery.LastStatusCode = &dbDelivery.LastStatusCode.Int32
+	}
+	if dbDelivery.DeliveredAt.Valid {
+		delivery.DeliveredAt = &dbDelivery.DeliveredAt.Time
+	}
+	return delivery
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsCreate(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// parse body
+	type parameters struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	type response struct {
+		WebhookEndpoint
+		Secret string `json:"secret"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	// validations
+	u, err := url.Parse(params.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondWithError(w, http.StatusBadRequest, "URL must be an absolute http or https URL", err)
+		return
+	}
+	if len(params.Events) == 0 {
+		respondWithError(w, http.StatusBadRequest, "At least one event is required", nil)
+		return
+	}
+	for _, event := range params.Events {
+		if _, ok := outboundWebhookEvents[event]; !ok {
+			respondWithError(w, http.StatusBadRequest, "Unknown event: "+event, nil)
+			return
+		}
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate webhook secret", err)
+		return
+	}
+
+	dbEndpoint, err := cfg.db.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID: userID,
+		Url:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create webhook endpoint", err)
+		return
+	}
+
+	// the secret is only ever shown once
+	respondWithJSON(w, http.StatusCreated, response{
+		WebhookEndpoint: webhookEndpointFromDB(dbEndpoint),
+		Secret:          secret,
+	})
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsList(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	dbEndpoints, err := cfg.db.GetWebhookEndpointsByUserID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook endpoints", err)
+		return
+	}
+
+	endpoints := []WebhookEndpoint{}
+	for _, dbEndpoint := range dbEndpoints {
+		endpoints = append(endpoints, webhookEndpointFromDB(dbEndpoint))
+	}
+
+	respondWithJSON(w, http.StatusOK, endpoints)
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsDelete(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteWebhookEndpoint(r.Context(), dbEndpoint.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete webhook endpoint", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsEnable(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	dbEndpoint, err := cfg.db.EnableWebhookEndpoint(r.Context(), dbEndpoint.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to enable webhook endpoint", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, webhookEndpointFromDB(dbEndpoint))
+}
+
+func (cfg *apiConfig) handlerWebhookDeliveriesList(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := cfg.ownedWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultWebhookDeliveriesLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(n, maxWebhookDeliveriesLimit)
+	}
+
+	dbDeliveries, err := cfg.db.GetWebhookDeliveriesByEndpointID(r.Context(), database.GetWebhookDeliveriesByEndpointIDParams{
+		EndpointID: dbEndpoint.ID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook deliveries", err)
+		return
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, dbDelivery := range dbDeliveries {
+		deliveries = append(deliveries, webhookDeliveryFromDB(dbDelivery))
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// ownedWebhookEndpoint loads the endpoint named in the path and checks that it
+// belongs to the caller, responding with an error if not.
+func (cfg *apiConfig) ownedWebhookEndpoint(w http.ResponseWriter, r *http.Request) (database.WebhookEndpoint, bool) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	id, err := uuid.Parse(r.PathValue("endpointID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook endpoint ID", err)
+		return database.WebhookEndpoint{}, false
+	}
+
+	dbEndpoint, err := cfg.db.GetWebhookEndpointByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Webhook endpoint not found", err)
+		return database.WebhookEndpoint{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook endpoint", err)
+		return database.WebhookEndpoint{}, false
+	}
+
+	if dbEndpoint.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You are not the owner of this webhook endpoint", nil)
+		return database.WebhookEndpoint{}, false
+	}
+
+	return dbEndpoint, true
+}
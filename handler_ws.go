@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	WSChannelTimeline      = "timeline"
+	WSChannelNotifications = "notifications"
+	wsThreadChannelPrefix  = "thread:"
+
+	wsSendQueueSize   = 64
+	wsMaxMessageBytes = 4 << 10
+	wsMaxThreads      = 50
+	wsWriteWait       = 10 * time.Second
+	wsPongWait        = 60 * time.Second
+	wsPingInterval    = wsPongWait * 9 / 10
+
+	// application close codes, from the range reserved for private use
+	wsCloseTokenExpired = 4001
+	wsCloseSlowConsumer = 4008
+)
+
+// mobile clients send no Origin header, which the default check allows;
+// browsers may only connect from the same host
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Token   string `json:"token"`
+}
+
+type wsServerMessage struct {
+	Type      string          `json:"type"`
+	Channel   string          `json:"channel,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	ID        int64           `json:"id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// wsConn is one authenticated WebSocket connection. All writes go through
+// its send queue, which a single writer goroutine drains; a connection whose
+// queue fills up is closed rather than allowed to hold up event delivery.
+type wsConn struct {
+	cfg    *apiConfig
+	conn   *websocket.Conn
+	userID uuid.UUID
+	hidden map[uuid.UUID]bool
+	send   chan []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	mu            sync.Mutex
+	expiresAt     time.Time
+	timeline      bool
+	notifications bool
+	threads       map[int32]bool
+}
+
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	// get userID from context
+	userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+	// the middleware already validated the token; the connection lasts only
+	// as long as it does unless the client sends a fresh one
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+	expiresAt, err := auth.TokenExpiry(token)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid credentials", err)
+		return
+	}
+
+	// blocked and muted authors are left out, as in the timeline
+	hiddenIDs, err := cfg.db.GetHiddenAuthorIDs(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't open connection", err)
+		return
+	}
+	hidden := map[uuid.UUID]bool{}
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+
+	// Upgrade responds with an error itself when the handshake fails
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &wsConn{
+		cfg:       cfg,
+		conn:      conn,
+		userID:    userID,
+		hidden:    hidden,
+		send:      make(chan []byte, wsSendQueueSize),
+		done:      make(chan struct{}),
+		expiresAt: expiresAt,
+		threads:   map[int32]bool{},
+	}
+
+	chirpSub := cfg.chirpStream.Subscribe()
+	defer cfg.chirpStream.Unsubscribe(chirpSub)
+	notificationSub := cfg.notificationStream.Subscribe()
+	defer cfg.notificationStream.Unsubscribe(notificationSub)
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writePump()
+	}()
+	go c.eventPump(chirpSub, notificationSub)
+
+	c.readPump(r.Context())
+	<-writerDone
+}
+
+// close ends the connection with the given close code. Only the first call
+// has any effect.
+func (c *wsConn) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// enqueue queues msg for the writer. If the queue is full the client isn't
+// keeping up, so the connection is closed.
+func (c *wsConn) enqueue(msg wsServerMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	select {
+	case <-c.done:
+	case c.send <- data:
+	default:
+		c.close(wsCloseSlowConsumer, "Send queue full")
+	}
+}
+
+func (c *wsConn) sendError(text string) {
+	c.enqueue(wsServerMessage{Type: "error", Error: text})
+}
+
+func (c *wsConn) writePump() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeText), time.Now().Add(wsWriteWait))
+			return
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) readPump(ctx context.Context) {
+	c.conn.SetReadLimit(wsMaxMessageBytes)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.close(websocket.CloseNormalClosure, "")
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		msg := wsClientMessage{}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.sendError("Invalid message")
+			continue
+		}
+		c.handleMessage(ctx, msg)
+	}
+}
+
+func (c *wsConn) handleMessage(ctx context.Context, msg wsClientMessage) {
+	switch msg.Type {
+	case "ping":
+		c.enqueue(wsServerMessage{Type: "pong"})
+	case "subscribe":
+		if errText := c.subscribe(ctx, msg.Channel); errText != "" {
+			c.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: errText})
+			return
+		}
+		c.enqueue(wsServerMessage{Type: "subscribed", Channel: msg.Channel})
+	case "unsubscribe":
+		if errText := c.unsubscribe(msg.Channel); errText != "" {
+			c.enqueue(wsServerMessage{Type: "error", Channel: msg.Channel, Error: errText})
+			return
+		}
+		c.enqueue(wsServerMessage{Type: "unsubscribed", Channel: msg.Channel})
+	case "auth":
+		c.reauthenticate(ctx, msg.Token)
+	default:
+		c.sendError("Unknown message type")
+	}
+}
+
+// parseThreadChannel returns the chirp ID of a "thread:{chirpID}" channel.
+func parseThreadChannel(channel string) (int32, bool) {
+	s, ok := strings.CutPrefix(channel, wsThreadChannelPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (c *wsConn) subscribe(ctx context.Context, channel string) string {
+	switch channel {
+	case WSChannelTimeline:
+		c.mu.Lock()
+		c.timeline = true
+		c.mu.Unlock()
+		return ""
+	case WSChannelNotifications:
+		c.mu.Lock()
+		c.notifications = true
+		c.mu.Unlock()
+		return ""
+	}
+
+	chirpID, ok := parseThreadChannel(channel)
+	if !ok {
+		return "Unknown channel"
+	}
+	// only threads the user can see can be followed
+	if _, err := c.cfg.db.GetShareableChirpByID(ctx, database.GetShareableChirpByIDParams{
+		ID:     chirpID,
+		UserID: c.userID,
+	}); err != nil {
+		return "Chirp not found"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.threads[chirpID] && len(c.threads) >= wsMaxThreads {
+		return "Too many thread subscriptions"
+	}
+	c.threads[chirpID] = true
+	return ""
+}
+
+func (c *wsConn) unsubscribe(channel string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch channel {
+	case WSChannelTimeline:
+		c.timeline = false
+		return ""
+	case WSChannelNotifications:
+		c.notifications = false
+		return ""
+	}
+
+	chirpID, ok := parseThreadChannel(channel)
+	if !ok {
+		return "Unknown channel"
+	}
+	delete(c.threads, chirpID)
+	return ""
+}
+
+// reauthenticate extends the connection with a fresh access token for the
+// same user. A rejected token leaves the current one in force until it
+// expires.
+func (c *wsConn) reauthenticate(ctx context.Context, token string) {
+	userID, err := auth.ValidateJWT(token, c.cfg.jwtSecret)
+	if err != nil || userID != c.userID {
+		c.sendError("Invalid credentials")
+		return
+	}
+	expiresAt, err := auth.TokenExpiry(token)
+	if err != nil {
+		c.sendError("Invalid credentials")
+		return
+	}
+
+	// tokens outlive suspensions and bans, so check the account is still in good standing
+	status, err := c.cfg.userStatus(ctx, userID)
+	if err != nil {
+		c.sendError("Invalid credentials")
+		return
+	}
+	if restriction := status.restriction(time.Now()); restriction != "" {
+		c.close(websocket.ClosePolicyViolation, restriction)
+		return
+	}
+
+	c.mu.Lock()
+	c.expiresAt = expiresAt
+	c.mu.Unlock()
+	c.enqueue(wsServerMessage{Type: "authenticated", ExpiresAt: &expiresAt})
+}
+
+// eventPump forwards stream events the connection is subscribed to and closes
+// it once its token expires.
+func (c *wsConn) eventPump(chirpSub, notificationSub *stream.Subscription) {
+	c.mu.Lock()
+	expiry := time.NewTimer(time.Until(c.expiresAt))
+	c.mu.Unlock()
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-expiry.C:
+			c.mu.Lock()
+			remaining := time.Until(c.expiresAt)
+			c.mu.Unlock()
+			// the client sent a fresh token in the meantime
+			if remaining > 0 {
+				expiry.Reset(remaining)
+				continue
+			}
+			c.close(wsCloseTokenExpired, "Token expired")
+			return
+		case event, ok := <-chirpSub.Events():
+			if !ok {
+				c.close(wsCloseSlowConsumer, "Too far behind")
+				return
+			}
+			c.deliverChirpEvent(event)
+		case event, ok := <-notificationSub.Events():
+			if !ok {
+				c.close(wsCloseSlowConsumer, "Too far behind")
+				return
+			}
+			c.mu.Lock()
+			subscribed := c.notifications
+			c.mu.Unlock()
+			if subscribed && event.UserID == c.userID {
+				c.enqueue(wsServerMessage{Type: "event", Channel: WSChannelNotifications, Event: event.Type, Data: event.Data})
+			}
+		}
+	}
+}
+
+// deliverChirpEvent sends event to the timeline and to the threads of the
+// chirp itself and of the chirp it quotes.
+func (c *wsConn) deliverChirpEvent(event stream.Event) {
+	if c.hidden[event.UserID] {
+		return
+	}
+	ref := chirpEventRef{}
+	if err := json.Unmarshal(event.Data, &ref); err != nil {
+		return
+	}
+
+	channels := []string{}
+	c.mu.Lock()
+	if c.timeline {
+		channels = append(channels, WSChannelTimeline)
+	}
+	if c.threads[ref.ID] {
+		channels = append(channels, wsThreadChannelPrefix+strconv.Itoa(int(ref.ID)))
+	}
+	if ref.QuoteOf != nil && c.threads[*ref.QuoteOf] {
+		channels = append(channels, wsThreadChannelPrefix+strconv.Itoa(int(*ref.QuoteOf)))
+	}
+	c.mu.Unlock()
+
+	for _, channel := range channels {
+		c.enqueue(wsServerMessage{Type: "event", Channel: channel, Event: event.Type, ID: event.ID, Data: event.Data})
+	}
+}
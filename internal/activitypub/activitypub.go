@@ -0,0 +1,176 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	ContentType    = "application/activity+json"
+	ContentTypeJRD = "application/jrd+json"
+	// AcceptHeader is what to send when fetching objects; Mastodon only
+	// returns ActivityStreams for these media types
+	AcceptHeader = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	SecurityContext        = "https://w3id.org/security/v1"
+	PublicCollection       = "https://www.w3.org/ns/activitystreams#Public"
+
+	TypeCreate = "Create"
+	TypeDelete = "Delete"
+	TypeFollow = "Follow"
+	TypeAccept = "Accept"
+	TypeUndo   = "Undo"
+
+	TypePerson            = "Person"
+	TypeNote              = "Note"
+	TypeTombstone         = "Tombstone"
+	TypeOrderedCollection = "OrderedCollection"
+)
+
+// PublicKey is the key an actor signs its requests with.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type Image struct {
+	Type      string `json:"type"`
+	MediaType string `json:"mediaType,omitempty"`
+	URL       string `json:"url"`
+}
+
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	Icon              *Image     `json:"icon,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// SharedInbox returns the actor's shared inbox, falling back to its own.
+func (a Actor) SharedInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo,omitempty"`
+	Content      string   `json:"content,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	Published    string   `json:"published,omitempty"`
+	To           []string `json:"to,omitempty"`
+	Cc           []string `json:"cc,omitempty"`
+}
+
+// Activity is an activity as sent or received. Object is left raw since it
+// may be a bare ID or an embedded object.
+type Activity struct {
+	Context any             `json:"@context,omitempty"`
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Actor   string          `json:"actor"`
+	Object  json.RawMessage `json:"object"`
+	To      []string        `json:"to,omitempty"`
+	Cc      []string        `json:"cc,omitempty"`
+}
+
+// ObjectID returns the ID of the activity's object, whether it was sent as a
+// bare ID or embedded.
+func (a Activity) ObjectID() string {
+	var id string
+	if err := json.Unmarshal(a.Object, &id); err == nil {
+		return id
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(a.Object, &obj); err == nil {
+		return obj.ID
+	}
+	return ""
+}
+
+// EmbeddedActivity decodes an embedded object as an activity, as found in
+// Undo and Accept.
+func (a Activity) EmbeddedActivity() (Activity, error) {
+	var inner Activity
+	if err := json.Unmarshal(a.Object, &inner); err != nil {
+		return Activity{}, err
+	}
+	if inner.Type == "" {
+		return Activity{}, errors.New("object is not an embedded activity")
+	}
+	return inner, nil
+}
+
+// NewActivity builds an activity from actor with object, which is marshalled
+// as given.
+func NewActivity(id, activityType, actor string, object any, to, cc []string) (Activity, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return Activity{}, err
+	}
+	return Activity{
+		Context: ActivityStreamsContext,
+		ID:      id,
+		Type:    activityType,
+		Actor:   actor,
+		Object:  data,
+		To:      to,
+		Cc:      cc,
+	}, nil
+}
+
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// WebFinger is a JSON Resource Descriptor as served from
+// /.well-known/webfinger.
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// ParseAcct splits an "acct:user@host" WebFinger resource.
+func ParseAcct(resource string) (user, host string, err error) {
+	acct, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return "", "", errors.New("resource is not an acct: URI")
+	}
+	user, host, ok = strings.Cut(acct, "@")
+	if !ok || user == "" || host == "" {
+		return "", "", errors.New("resource is not of the form acct:user@host")
+	}
+	return user, strings.ToLower(host), nil
+}
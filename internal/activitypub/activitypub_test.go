@@ -0,0 +1,355 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+)
+
+// newTestClient returns a client that may reach loopback addresses, where
+// httptest servers listen, but is otherwise as strict as in production.
+func newTestClient(allowHTTP bool) *Client {
+	return NewClient(5*time.Second, allowHTTP, func(addr netip.Addr) bool {
+		return addr.IsLoopback() || netguard.IsPublic(addr)
+	})
+}
+
+// remote is a stand-in for another server: it publishes one actor and
+// records the activities its inbox accepts.
+type remote struct {
+	server *httptest.Server
+	client *Client
+	signer Signer
+	actor  Actor
+
+	mu       sync.Mutex
+	received []Activity
+	inboxErr error
+}
+
+func newRemote(t *testing.T) *remote {
+	t.Helper()
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey returned error: %v", err)
+	}
+
+	rem := &remote{client: newTestClient(true)}
+	mux := http.NewServeMux()
+	rem.server = httptest.NewServer(mux)
+	t.Cleanup(rem.server.Close)
+
+	actorID := rem.server.URL + "/users/bob"
+	rem.actor = Actor{
+		Context:           []string{ActivityStreamsContext, SecurityContext},
+		ID:                actorID,
+		Type:              TypePerson,
+		PreferredUsername: "bob",
+		Inbox:             actorID + "/inbox",
+		PublicKey:         PublicKey{ID: actorID + "#main-key", Owner: actorID, PublicKeyPem: publicPEM},
+	}
+	rem.signer = Signer{KeyID: rem.actor.PublicKey.ID, Key: key}
+
+	mux.HandleFunc("GET /users/bob", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(rem.actor)
+	})
+	mux.HandleFunc("POST /users/bob/inbox", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signer, err := rem.client.VerifyRequest(r.Context(), r, body, rem.signer)
+		activity := Activity{}
+		if err == nil {
+			err = json.Unmarshal(body, &activity)
+		}
+		if err == nil && activity.Actor != signer.ID {
+			err = errors.New("activity actor is not the signer")
+		}
+
+		rem.mu.Lock()
+		defer rem.mu.Unlock()
+		if err != nil {
+			rem.inboxErr = err
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rem.received = append(rem.received, activity)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return rem
+}
+
+// local serves one local actor the remote can fetch to verify signatures.
+func newLocal(t *testing.T) (Actor, Signer) {
+	t.Helper()
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey returned error: %v", err)
+	}
+
+	var actor Actor
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(actor)
+	}))
+	t.Cleanup(server.Close)
+
+	actorID := server.URL + "/ap/users/alice"
+	actor = Actor{
+		ID:        actorID,
+		Type:      TypePerson,
+		Inbox:     actorID + "/inbox",
+		PublicKey: PublicKey{ID: actorID + "#main-key", Owner: actorID, PublicKeyPem: publicPEM},
+	}
+	return actor, Signer{KeyID: actor.PublicKey.ID, Key: key}
+}
+
+func TestDeliverIsVerifiedByRemote(t *testing.T) {
+	rem := newRemote(t)
+	local, signer := newLocal(t)
+	client := newTestClient(true)
+
+	note := Note{ID: local.ID + "/notes/1", Type: TypeNote, AttributedTo: local.ID, Content: "<p>hello</p>"}
+	activity, err := NewActivity(note.ID+"/activity", TypeCreate, local.ID, note, []string{PublicCollection}, nil)
+	if err != nil {
+		t.Fatalf("NewActivity returned error: %v", err)
+	}
+	body, _ := json.Marshal(activity)
+
+	if _, err := client.Deliver(context.Background(), rem.actor.Inbox, body, signer); err != nil {
+		t.Fatalf("Deliver returned error: %v (remote: %v)", err, rem.inboxErr)
+	}
+	if len(rem.received) != 1 || rem.received[0].Type != TypeCreate || rem.received[0].ObjectID() != note.ID {
+		t.Fatalf("remote received %+v", rem.received)
+	}
+}
+
+func TestDeliverSignedByWrongKeyIsRejected(t *testing.T) {
+	rem := newRemote(t)
+	local, _ := newLocal(t)
+	_, otherSigner := newLocal(t)
+	client := newTestClient(true)
+
+	activity, _ := NewActivity(local.ID+"#follow", TypeFollow, local.ID, rem.actor.ID, nil, nil)
+	body, _ := json.Marshal(activity)
+
+	// claims to be local's key but is signed by another one
+	forged := Signer{KeyID: local.PublicKey.ID, Key: otherSigner.Key}
+	status, err := client.Deliver(context.Background(), rem.actor.Inbox, body, forged)
+	if err == nil || status != http.StatusUnauthorized {
+		t.Fatalf("Deliver returned %d, %v; want 401", status, err)
+	}
+	if !errors.Is(rem.inboxErr, ErrInvalidSignature) {
+		t.Errorf("remote rejected with %v, want ErrInvalidSignature", rem.inboxErr)
+	}
+}
+
+func TestVerifyRequestRejectsTampering(t *testing.T) {
+	_, publicPEM, _ := GenerateKey()
+	privatePEM, ownPublicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	key, _ := ParsePrivateKey(privatePEM)
+	publicKey, _ := ParsePublicKey(ownPublicPEM)
+	otherKey, _ := ParsePublicKey(publicPEM)
+
+	now := time.Now()
+	body := []byte(`{"type":"Follow"}`)
+	newSigned := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "https://chirpy.example/ap/users/1/inbox", bytes.NewReader(body))
+		req.Header.Set("Content-Type", ContentType)
+		if err := SignRequest(req, "https://remote.example/users/bob#main-key", key, body, now); err != nil {
+			t.Fatalf("SignRequest returned error: %v", err)
+		}
+		return req
+	}
+
+	req := newSigned()
+	sig, err := ParseSignature(req.Header.Get("Signature"))
+	if err != nil {
+		t.Fatalf("ParseSignature returned error: %v", err)
+	}
+	if sig.ActorID() != "https://remote.example/users/bob" {
+		t.Errorf("ActorID = %q", sig.ActorID())
+	}
+	if err := VerifyRequest(req, body, sig, publicKey, now); err != nil {
+		t.Fatalf("VerifyRequest returned error for a valid request: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(req *http.Request) []byte
+		key    bool
+		at     time.Time
+	}{
+		{"body", func(req *http.Request) []byte { return []byte(`{"type":"Undo"}`) }, true, now},
+		{"target", func(req *http.Request) []byte { req.URL.Path = "/ap/users/2/inbox"; return body }, true, now},
+		{"host", func(req *http.Request) []byte { req.Host = "evil.example"; return body }, true, now},
+		{"stale", func(req *http.Request) []byte { return body }, true, now.Add(2 * MaxClockSkew)},
+		{"key", func(req *http.Request) []byte { return body }, false, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newSigned()
+			tamperedBody := tt.tamper(req)
+			verifyKey := publicKey
+			if !tt.key {
+				verifyKey = otherKey
+			}
+			if err := VerifyRequest(req, tamperedBody, sig, verifyKey, tt.at); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyRequest returned %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestParseSignatureRequiresKeyAndSignature(t *testing.T) {
+	for _, header := range []string{
+		"",
+		`keyId="https://remote.example/users/bob#main-key"`,
+		`signature="AAAA"`,
+		`keyId=unquoted,signature="AAAA"`,
+	} {
+		if _, err := ParseSignature(header); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ParseSignature(%q) returned %v, want ErrInvalidSignature", header, err)
+		}
+	}
+}
+
+func TestFetchActorRejectsMismatchedID(t *testing.T) {
+	rem := newRemote(t)
+	_, signer := newLocal(t)
+	client := newTestClient(true)
+
+	if _, err := client.FetchActor(context.Background(), rem.server.URL+"/users/bob", signer); err != nil {
+		t.Fatalf("FetchActor returned error: %v", err)
+	}
+	rem.actor.ID = rem.server.URL + "/users/mallory"
+	if _, err := client.FetchActor(context.Background(), rem.server.URL+"/users/bob", signer); err == nil {
+		t.Fatal("FetchActor accepted a document for a different actor")
+	}
+}
+
+func TestClientRefusesPlainHTTPByDefault(t *testing.T) {
+	rem := newRemote(t)
+	_, signer := newLocal(t)
+	client := newTestClient(false)
+
+	_, err := client.FetchActor(context.Background(), rem.server.URL+"/users/bob", signer)
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Fatalf("FetchActor returned %v, want a refusal", err)
+	}
+}
+
+func TestClientBlocksPrivateAddresses(t *testing.T) {
+	rem := newRemote(t)
+	_, signer := newLocal(t)
+	client := NewClient(5*time.Second, true, netguard.IsPublic)
+
+	_, err := client.FetchActor(context.Background(), rem.server.URL+"/users/bob", signer)
+	if !errors.Is(err, netguard.ErrBlockedAddress) {
+		t.Fatalf("FetchActor returned %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestVerifyRequestCachesActorKey(t *testing.T) {
+	privatePEM, publicPEM, _ := GenerateKey()
+	key, _ := ParsePrivateKey(privatePEM)
+	_, local := newLocal(t)
+
+	var fetches int
+	var actor Actor
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(actor)
+	}))
+	defer server.Close()
+	actor = Actor{
+		ID:        server.URL + "/users/carol",
+		Type:      TypePerson,
+		Inbox:     server.URL + "/users/carol/inbox",
+		PublicKey: PublicKey{ID: server.URL + "/users/carol#main-key", Owner: server.URL + "/users/carol", PublicKeyPem: publicPEM},
+	}
+
+	client := newTestClient(true)
+	body := []byte(`{"type":"Follow"}`)
+	verify := func(signingKey Signer) error {
+		req := httptest.NewRequest(http.MethodPost, "https://chirpy.example/ap/users/1/inbox", bytes.NewReader(body))
+		req.Header.Set("Content-Type", ContentType)
+		if err := SignRequest(req, signingKey.KeyID, signingKey.Key, body, time.Now()); err != nil {
+			t.Fatalf("SignRequest returned error: %v", err)
+		}
+		_, err := client.VerifyRequest(context.Background(), req, body, local)
+		return err
+	}
+
+	signer := Signer{KeyID: actor.PublicKey.ID, Key: key}
+	for range 3 {
+		if err := verify(signer); err != nil {
+			t.Fatalf("VerifyRequest returned error: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("actor fetched %d times, want 1", fetches)
+	}
+
+	// a bad signature against a fresh key doesn't trigger a refetch
+	forged := Signer{KeyID: actor.PublicKey.ID, Key: local.Key}
+	if err := verify(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("VerifyRequest returned %v, want ErrInvalidSignature", err)
+	}
+	if fetches != 1 {
+		t.Errorf("actor fetched %d times after a bad signature, want 1", fetches)
+	}
+}
+
+func TestActivityObjectID(t *testing.T) {
+	bare := Activity{Object: json.RawMessage(`"https://chirpy.example/ap/users/1"`)}
+	if got := bare.ObjectID(); got != "https://chirpy.example/ap/users/1" {
+		t.Errorf("bare ObjectID = %q", got)
+	}
+
+	undo := Activity{Object: json.RawMessage(`{"id":"https://remote.example/follows/1","type":"Follow","actor":"https://remote.example/users/bob","object":"https://chirpy.example/ap/users/1"}`)}
+	if got := undo.ObjectID(); got != "https://remote.example/follows/1" {
+		t.Errorf("embedded ObjectID = %q", got)
+	}
+	inner, err := undo.EmbeddedActivity()
+	if err != nil || inner.Type != TypeFollow || inner.ObjectID() != "https://chirpy.example/ap/users/1" {
+		t.Errorf("EmbeddedActivity = %+v, %v", inner, err)
+	}
+	if _, err := bare.EmbeddedActivity(); err == nil {
+		t.Error("EmbeddedActivity accepted a bare ID")
+	}
+}
+
+func TestParseAcct(t *testing.T) {
+	user, host, err := ParseAcct("acct:alice@Chirpy.Example")
+	if err != nil || user != "alice" || host != "chirpy.example" {
+		t.Errorf("ParseAcct = %q, %q, %v", user, host, err)
+	}
+	for _, resource := range []string{"alice@chirpy.example", "acct:alice", "acct:@chirpy.example"} {
+		if _, _, err := ParseAcct(resource); err == nil {
+			t.Errorf("ParseAcct(%q) returned no error", resource)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+)
+
+const (
+	// maxResponseBytes bounds remote documents, which are only ever small JSON.
+	maxResponseBytes = 1 << 20
+
+	// actorKeyTTL is how long a fetched actor and key are used to verify
+	// requests before being fetched again
+	actorKeyTTL = time.Hour
+	// keyRefetchInterval is how soon a cached key that stops verifying may be
+	// refetched, in case it was rotated; bad signatures alone can't make every
+	// request trigger a fetch
+	keyRefetchInterval = time.Minute
+	maxCachedKeys      = 10000
+)
+
+// Signer signs outgoing requests as one local actor.
+type Signer struct {
+	KeyID string
+	Key   *rsa.PrivateKey
+}
+
+// Client talks to remote servers. Actor URLs and inboxes come from untrusted
+// requests, so it only connects to addresses allow accepts; see
+// netguard.NewClient.
+type Client struct {
+	httpClient *http.Client
+	// allowHTTP permits plain-http remotes, for local development and tests
+	allowHTTP bool
+
+	mu   sync.Mutex
+	keys map[string]cachedKey
+}
+
+type cachedKey struct {
+	actor     Actor
+	key       *rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewClient(timeout time.Duration, allowHTTP bool, allow func(netip.Addr) bool) *Client {
+	c := &Client{
+		httpClient: netguard.NewClient(timeout, allow),
+		allowHTTP:  allowHTTP,
+		keys:       map[string]cachedKey{},
+	}
+	c.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := netguard.CheckRedirect(req, via); err != nil {
+			return err
+		}
+		return c.checkURL(req.URL.String())
+	}
+	return c
+}
+
+func (c *Client) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" || (u.Scheme != "https" && (u.Scheme != "http" || !c.allowHTTP)) {
+		return fmt.Errorf("refusing to contact %q", rawURL)
+	}
+	return nil
+}
+
+// FetchActor GETs an actor document, signed by signer since servers running
+// in secure mode refuse unsigned fetches.
+func (c *Client) FetchActor(ctx context.Context, actorURL string, signer Signer) (Actor, error) {
+	if err := c.checkURL(actorURL); err != nil {
+		return Actor{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actorURL, nil)
+	if err != nil {
+		return Actor{}, err
+	}
+	req.Header.Set("Accept", AcceptHeader)
+	if err := SignRequest(req, signer.KeyID, signer.Key, nil, time.Now()); err != nil {
+		return Actor{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Actor{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Actor{}, fmt.Errorf("fetching actor responded with status %d", resp.StatusCode)
+	}
+
+	actor := Actor{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&actor); err != nil {
+		return Actor{}, fmt.Errorf("invalid actor document: %w", err)
+	}
+	// the document must be the one asked for, not some other actor
+	if actor.ID != actorURL || actor.Inbox == "" {
+		return Actor{}, fmt.Errorf("invalid actor document for %s", actorURL)
+	}
+	if actor.PublicKey.Owner != "" && actor.PublicKey.Owner != actor.ID {
+		return Actor{}, fmt.Errorf("actor %s publishes a key it doesn't own", actorURL)
+	}
+	return actor, nil
+}
+
+// Deliver POSTs an activity to an inbox, signed by signer. It returns the
+// response status code, and an error for transport failures and non-2xx
+// responses.
+func (c *Client) Deliver(ctx context.Context, inbox string, body []byte, signer Signer) (int, error) {
+	if err := c.checkURL(inbox); err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err := SignRequest(req, signer.KeyID, signer.Key, body, time.Now()); err != nil {
+		return 0, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("inbox responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// VerifyRequest checks the HTTP signature on an incoming request and returns
+// the actor that signed it, fetching the actor to get its key unless it was
+// fetched recently. body is the request body, already read.
+func (c *Client) VerifyRequest(ctx context.Context, r *http.Request, body []byte, signer Signer) (Actor, error) {
+	sig, err := ParseSignature(r.Header.Get("Signature"))
+	if err != nil {
+		return Actor{}, err
+	}
+	now := time.Now()
+	if err := checkRequest(r, body, sig, now); err != nil {
+		return Actor{}, err
+	}
+
+	if cached, ok := c.cachedKey(sig.KeyID, now); ok {
+		err := verifySignature(r, sig, cached.key)
+		if err == nil {
+			return cached.actor, nil
+		}
+		if now.Sub(cached.fetchedAt) < keyRefetchInterval {
+			return Actor{}, err
+		}
+	}
+
+	actor, err := c.FetchActor(ctx, sig.ActorID(), signer)
+	if err != nil {
+		return Actor{}, fmt.Errorf("%w: couldn't fetch signing actor: %w", ErrInvalidSignature, err)
+	}
+	if actor.PublicKey.ID != sig.KeyID {
+		return Actor{}, fmt.Errorf("%w: key %s is not %s's", ErrInvalidSignature, sig.KeyID, actor.ID)
+	}
+	key, err := ParsePublicKey(actor.PublicKey.PublicKeyPem)
+	if err != nil {
+		return Actor{}, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	c.storeKey(sig.KeyID, cachedKey{actor: actor, key: key, fetchedAt: now})
+
+	if err := verifySignature(r, sig, key); err != nil {
+		return Actor{}, err
+	}
+	return actor, nil
+}
+
+func (c *Client) cachedKey(keyID string, now time.Time) (cachedKey, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.keys[keyID]
+	if !ok || now.Sub(cached.fetchedAt) > actorKeyTTL {
+		return cachedKey{}, false
+	}
+	return cached, true
+}
+
+func (c *Client) storeKey(keyID string, cached cachedKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.keys) >= maxCachedKeys {
+		for id, other := range c.keys {
+			if cached.fetchedAt.Sub(other.fetchedAt) > actorKeyTTL {
+				delete(c.keys, id)
+			}
+		}
+		// still full of live keys, so start over rather than grow
+		if len(c.keys) >= maxCachedKeys {
+			clear(c.keys)
+		}
+	}
+	c.keys[keyID] = cached
+}
@@ -74,6 +74,23 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	return userID, nil
 }
 
+// TokenExpiry returns when an access token expires. It doesn't verify the
+// token, so only call it on tokens that passed ValidateJWT.
+func TokenExpiry(tokenString string) (time.Time, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &jwt.RegisteredClaims{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	exp, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if exp == nil {
+		return time.Time{}, fmt.Errorf("token has no expiry")
+	}
+	return exp.Time, nil
+}
+
 func GetBearerToken(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
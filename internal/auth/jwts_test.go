@@ -3,6 +3,7 @@ package auth
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -82,3 +83,24 @@ func TestValidateTamperedToken(t *testing.T) {
 		t.Errorf("ValidateJWT returned userID %q for tampered token; want Nil", gotID)
 	}
 }
+
+// expiry: the token expires AccessTokenExpiration after it was made
+func TestTokenExpiry(t *testing.T) {
+	before := time.Now().Truncate(time.Second)
+	tokenString, err := MakeJWT(uuid.New(), "expiry-secret")
+	if err != nil {
+		t.Fatalf("makeJWT returned error: %v", err)
+	}
+
+	exp, err := TokenExpiry(tokenString)
+	if err != nil {
+		t.Fatalf("TokenExpiry returned unexpected error: %v", err)
+	}
+	if want := before.Add(AccessTokenExpiration); exp.Before(want) || exp.After(want.Add(time.Minute)) {
+		t.Errorf("TokenExpiry returned %v, want about %v", exp, want)
+	}
+
+	if _, err := TokenExpiry("this-is-not-a-jwt"); err == nil {
+		t.Fatal("TokenExpiry did not return error for malformed token")
+	}
+}
@@ -0,0 +1,52 @@
+package chirptext
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisible are the characters Normalize strips: zero-width spaces and
+// joiners that hide text or pad it invisibly, and the bidi embeddings,
+// overrides and isolates that can make text display differently from how it
+// reads. The zero-width joiner and non-joiner are kept, since emoji sequences
+// and several scripts depend on them.
+var invisible = map[rune]bool{
+	'\u200b': true, // zero width space
+	'\u2060': true, // word joiner
+	'\ufeff': true, // zero width no-break space, or byte order mark
+	'\u202a': true, // left-to-right embedding
+	'\u202b': true, // right-to-left embedding
+	'\u202c': true, // pop directional formatting
+	'\u202d': true, // left-to-right override
+	'\u202e': true, // right-to-left override
+	'\u2066': true, // left-to-right isolate
+	'\u2067': true, // right-to-left isolate
+	'\u2068': true, // first strong isolate
+	'\u2069': true, // pop directional isolate
+}
+
+// Normalize prepares a chirp body for counting and storage: it strips
+// invisible characters, converts line endings to \n, applies Unicode NFC
+// normalization and trims surrounding whitespace. A body that is empty
+// afterwards had no visible content.
+func Normalize(body string) string {
+	body = strings.Map(func(r rune) rune {
+		if invisible[r] {
+			return -1
+		}
+		return r
+	}, body)
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\r", "\n")
+	body = norm.NFC.String(body)
+	return strings.TrimSpace(body)
+}
+
+// Length counts the user-perceived characters (extended grapheme clusters)
+// in s, so an emoji sequence or a syllable written with combining marks
+// counts once, however many code points or bytes it takes.
+func Length(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
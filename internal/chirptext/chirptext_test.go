@@ -0,0 +1,54 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"plain", "hello world", "hello world"},
+		{"trims whitespace", "  \t hello\n\n", "hello"},
+		{"line endings", "one\r\ntwo\rthree", "one\ntwo\nthree"},
+		{"zero width space", "he\u200bllo\ufeff", "hello"},
+		{"bidi override", "file\u202egpj.exe", "filegpj.exe"},
+		{"bidi isolate", "\u2067abc\u2069", "abc"},
+		{"keeps zero width joiner", "👩\u200d💻", "👩\u200d💻"},
+		{"nfc", "cafe\u0301", "café"},
+		{"whitespace only", " \u3000 \n", ""},
+		{"invisible only", "\u200b\u202e\u200b", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.body); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"नमस्ते", 4},
+		{"👍🏽", 1},
+		{"👩\u200d👩\u200d👧\u200d👦", 1},
+		{"🇳🇿🇯🇵", 2},
+		{strings.Repeat("😀", 140), 140},
+	}
+	for _, tt := range tests {
+		if got := Length(tt.s); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: activitypub.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const addAPFollower = `-- name: AddAPFollower :exec
+INSERT INTO ap_followers (user_id, actor_id, inbox, shared_inbox, follow_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, actor_id) DO UPDATE SET
+    inbox = EXCLUDED.inbox,
+    shared_inbox = EXCLUDED.shared_inbox,
+    follow_id = EXCLUDED.follow_id
+`
+
+type AddAPFollowerParams struct {
+	UserID      uuid.UUID
+	ActorID     string
+	Inbox       string
+	SharedInbox sql.NullString
+	FollowID    string
+}
+
+func (q *Queries) AddAPFollower(ctx context.Context, arg AddAPFollowerParams) error {
+	_, err := q.db.ExecContext(ctx, addAPFollower,
+		arg.UserID,
+		arg.ActorID,
+		arg.Inbox,
+		arg.SharedInbox,
+		arg.FollowID,
+	)
+	return err
+}
+
+const claimDueAPDeliveries = `-- name: ClaimDueAPDeliveries :many
+WITH due AS (
+    SELECT ap_deliveries.id FROM ap_deliveries
+    WHERE ap_deliveries.status = 'pending'
+        AND ap_deliveries.next_attempt_at <= NOW()
+    ORDER BY ap_deliveries.next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE ap_deliveries SET
+    attempts = ap_deliveries.attempts + 1,
+    next_attempt_at = NOW() + ($1::int * INTERVAL '1 second'),
+    updated_at = NOW()
+FROM due
+WHERE ap_deliveries.id = due.id
+RETURNING ap_deliveries.id, ap_deliveries.user_id, ap_deliveries.inbox, ap_deliveries.payload, ap_deliveries.status, ap_deliveries.attempts, ap_deliveries.next_attempt_at, ap_deliveries.last_status_code, ap_deliveries.last_error, ap_deliveries.delivered_at, ap_deliveries.created_at, ap_deliveries.updated_at
+`
+
+type ClaimDueAPDeliveriesParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimDueAPDeliveries(ctx context.Context, arg ClaimDueAPDeliveriesParams) ([]ApDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueAPDeliveries, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApDelivery
+	for rows.Next() {
+		var i ApDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Inbox,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countAPFollowers = `-- name: CountAPFollowers :one
+SELECT COUNT(*) FROM ap_followers
+WHERE user_id = $1
+`
+
+func (q *Queries) CountAPFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAPFollowers, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPKey = `-- name: CreateAPKey :exec
+INSERT INTO ap_keys (user_id, private_key, public_key)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO NOTHING
+`
+
+type CreateAPKeyParams struct {
+	UserID     uuid.UUID
+	PrivateKey string
+	PublicKey  string
+}
+
+func (q *Queries) CreateAPKey(ctx context.Context, arg CreateAPKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createAPKey, arg.UserID, arg.PrivateKey, arg.PublicKey)
+	return err
+}
+
+const enqueueAPDelivery = `-- name: EnqueueAPDelivery :exec
+INSERT INTO ap_deliveries (id, user_id, inbox, payload)
+VALUES (gen_random_uuid(), $1, $2, $3)
+`
+
+type EnqueueAPDeliveryParams struct {
+	UserID  uuid.UUID
+	Inbox   string
+	Payload json.RawMessage
+}
+
+func (q *Queries) EnqueueAPDelivery(ctx context.Context, arg EnqueueAPDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, enqueueAPDelivery, arg.UserID, arg.Inbox, arg.Payload)
+	return err
+}
+
+const enqueueAPFollowerDeliveries = `-- name: EnqueueAPFollowerDeliveries :execrows
+INSERT INTO ap_deliveries (id, user_id, inbox, payload)
+SELECT gen_random_uuid(), $1::uuid, inboxes.inbox, $2::jsonb
+FROM (
+    SELECT DISTINCT COALESCE(ap_followers.shared_inbox, ap_followers.inbox) AS inbox
+    FROM ap_followers
+    WHERE ap_followers.user_id = $1::uuid
+) AS inboxes
+`
+
+type EnqueueAPFollowerDeliveriesParams struct {
+	UserID  uuid.UUID
+	Payload json.RawMessage
+}
+
+// followers on the same server share one delivery to its shared inbox
+func (q *Queries) EnqueueAPFollowerDeliveries(ctx context.Context, arg EnqueueAPFollowerDeliveriesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueAPFollowerDeliveries, arg.UserID, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAPKey = `-- name: GetAPKey :one
+SELECT user_id, private_key, public_key, created_at FROM ap_keys
+WHERE user_id = $1
+`
+
+func (q *Queries) GetAPKey(ctx context.Context, userID uuid.UUID) (ApKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPKey, userID)
+	var i ApKey
+	err := row.Scan(
+		&i.UserID,
+		&i.PrivateKey,
+		&i.PublicKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const markAPDeliveryFailed = `-- name: MarkAPDeliveryFailed :exec
+UPDATE ap_deliveries SET
+    status = $1,
+    last_status_code = $2,
+    last_error = $3,
+    next_attempt_at = NOW() + ($4::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = $5
+`
+
+type MarkAPDeliveryFailedParams struct {
+	Status            string
+	LastStatusCode    sql.NullInt32
+	LastError         sql.NullString
+	RetryAfterSeconds int32
+	ID                uuid.UUID
+}
+
+func (q *Queries) MarkAPDeliveryFailed(ctx context.Context, arg MarkAPDeliveryFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markAPDeliveryFailed,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.RetryAfterSeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const markAPDeliverySucceeded = `-- name: MarkAPDeliverySucceeded :exec
+UPDATE ap_deliveries SET
+    status = 'succeeded',
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type MarkAPDeliverySucceededParams struct {
+	ID             uuid.UUID
+	LastStatusCode sql.NullInt32
+}
+
+func (q *Queries) MarkAPDeliverySucceeded(ctx context.Context, arg MarkAPDeliverySucceededParams) error {
+	_, err := q.db.ExecContext(ctx, markAPDeliverySucceeded, arg.ID, arg.LastStatusCode)
+	return err
+}
+
+const removeAPFollower = `-- name: RemoveAPFollower :execrows
+DELETE FROM ap_followers
+WHERE user_id = $1 AND actor_id = $2
+`
+
+type RemoveAPFollowerParams struct {
+	UserID  uuid.UUID
+	ActorID string
+}
+
+func (q *Queries) RemoveAPFollower(ctx context.Context, arg RemoveAPFollowerParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeAPFollower, arg.UserID, arg.ActorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
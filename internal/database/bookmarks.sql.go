@@ -0,0 +1,153 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const bookmarkChirp = `-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type BookmarkChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID int32
+}
+
+func (q *Queries) BookmarkChirp(ctx context.Context, arg BookmarkChirpParams) error {
+	_, err := q.db.ExecContext(ctx, bookmarkChirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const getBookmarkedChirpIDs = `-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = $1 AND chirp_id = ANY($2::int[])
+`
+
+type GetBookmarkedChirpIDsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []int32
+}
+
+func (q *Queries) GetBookmarkedChirpIDs(ctx context.Context, arg GetBookmarkedChirpIDsParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedChirpIDs, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var chirp_id int32
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarksByUserID = `-- name: GetBookmarksByUserID :many
+SELECT bookmarks.created_at AS bookmarked_at, chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+JOIN users ON users.id = chirps.user_id
+WHERE bookmarks.user_id = $1
+    -- the cursor is (created_at, chirp_id), so bookmarks made at the same
+    -- moment aren't skipped between pages
+    AND ($2::timestamp IS NULL
+        OR bookmarks.created_at < $2
+        OR (bookmarks.created_at = $2 AND bookmarks.chirp_id < $3::int))
+    AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = $1 AND blocks.blocked_id = chirps.user_id
+    )
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT $4
+`
+
+type GetBookmarksByUserIDParams struct {
+	UserID   uuid.UUID
+	Before   sql.NullTime
+	BeforeID sql.NullInt32
+	Limit    int32
+}
+
+type GetBookmarksByUserIDRow struct {
+	BookmarkedAt time.Time
+	Chirp        Chirp
+}
+
+func (q *Queries) GetBookmarksByUserID(ctx context.Context, arg GetBookmarksByUserIDParams) ([]GetBookmarksByUserIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarksByUserID,
+		arg.UserID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookmarksByUserIDRow
+	for rows.Next() {
+		var i GetBookmarksByUserIDRow
+		if err := rows.Scan(
+			&i.BookmarkedAt,
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PublishAt,
+			&i.Chirp.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unbookmarkChirp = `-- name: UnbookmarkChirp :execrows
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2
+`
+
+type UnbookmarkChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID int32
+}
+
+func (q *Queries) UnbookmarkChirp(ctx context.Context, arg UnbookmarkChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unbookmarkChirp, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,140 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: chirp_events.sql
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const createChirpEvent = `-- name: CreateChirpEvent :exec
+WITH event AS (
+    INSERT INTO chirp_events (type, chirp_id, user_id, payload)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id
+)
+SELECT pg_notify('chirp_events', event.id::text) FROM event
+`
+
+type CreateChirpEventParams struct {
+	Type    string
+	ChirpID int32
+	UserID  uuid.UUID
+	Payload json.RawMessage
+}
+
+// the notification is only delivered once the surrounding transaction
+// commits, and tells listeners there is an event to sequence
+func (q *Queries) CreateChirpEvent(ctx context.Context, arg CreateChirpEventParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpEvent,
+		arg.Type,
+		arg.ChirpID,
+		arg.UserID,
+		arg.Payload,
+	)
+	return err
+}
+
+const getChirpEventsAfter = `-- name: GetChirpEventsAfter :many
+SELECT id, type, chirp_id, user_id, payload, created_at, seq FROM chirp_events
+WHERE seq > $1::bigint
+ORDER BY seq
+LIMIT $2
+`
+
+type GetChirpEventsAfterParams struct {
+	Seq   int64
+	Limit int32
+}
+
+func (q *Queries) GetChirpEventsAfter(ctx context.Context, arg GetChirpEventsAfterParams) ([]ChirpEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpEventsAfter, arg.Seq, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpEvent
+	for rows.Next() {
+		var i ChirpEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.ChirpID,
+			&i.UserID,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestChirpEventSeq = `-- name: GetLatestChirpEventSeq :one
+SELECT COALESCE(MAX(seq), 0)::bigint FROM chirp_events
+`
+
+func (q *Queries) GetLatestChirpEventSeq(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestChirpEventSeq)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const lockChirpEventSequencer = `-- name: LockChirpEventSequencer :exec
+SELECT pg_advisory_xact_lock(hashtext('chirp_event_seq'))
+`
+
+// held until the transaction ends, so sequenced events commit in seq order
+func (q *Queries) LockChirpEventSequencer(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockChirpEventSequencer)
+	return err
+}
+
+const notifyChirpEventsSequenced = `-- name: NotifyChirpEventsSequenced :exec
+SELECT pg_notify('chirp_events_sequenced', '')
+`
+
+func (q *Queries) NotifyChirpEventsSequenced(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, notifyChirpEventsSequenced)
+	return err
+}
+
+const pruneChirpEvents = `-- name: PruneChirpEvents :execrows
+DELETE FROM chirp_events
+WHERE created_at < NOW() - ($1::int * INTERVAL '1 second')
+`
+
+func (q *Queries) PruneChirpEvents(ctx context.Context, retentionSeconds int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneChirpEvents, retentionSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sequenceChirpEvents = `-- name: SequenceChirpEvents :execrows
+UPDATE chirp_events SET seq = nextval('chirp_event_seq')
+WHERE seq IS NULL
+`
+
+func (q *Queries) SequenceChirpEvents(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, sequenceChirpEvents)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
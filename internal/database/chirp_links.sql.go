@@ -0,0 +1,211 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: chirp_links.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const addChirpLink = `-- name: AddChirpLink :exec
+WITH preview AS (
+    INSERT INTO link_previews (url)
+    VALUES ($3)
+    ON CONFLICT (url) DO NOTHING
+)
+INSERT INTO chirp_links (chirp_id, position, url)
+VALUES ($1, $2, $3)
+`
+
+type AddChirpLinkParams struct {
+	ChirpID  int32
+	Position int32
+	Url      string
+}
+
+func (q *Queries) AddChirpLink(ctx context.Context, arg AddChirpLinkParams) error {
+	_, err := q.db.ExecContext(ctx, addChirpLink, arg.ChirpID, arg.Position, arg.Url)
+	return err
+}
+
+const claimDueLinkPreviews = `-- name: ClaimDueLinkPreviews :many
+WITH due AS (
+    SELECT link_previews.url FROM link_previews
+    WHERE link_previews.status = 'pending'
+        AND link_previews.next_attempt_at <= NOW()
+    ORDER BY link_previews.next_attempt_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE link_previews SET
+    attempts = link_previews.attempts + 1,
+    next_attempt_at = NOW() + ($1::int * INTERVAL '1 second'),
+    updated_at = NOW()
+FROM due
+WHERE link_previews.url = due.url
+RETURNING link_previews.url, link_previews.status, link_previews.title, link_previews.description, link_previews.image_url, link_previews.site_name, link_previews.attempts, link_previews.next_attempt_at, link_previews.last_error, link_previews.fetched_at, link_previews.created_at, link_previews.updated_at
+`
+
+type ClaimDueLinkPreviewsParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimDueLinkPreviews(ctx context.Context, arg ClaimDueLinkPreviewsParams) ([]LinkPreview, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueLinkPreviews, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LinkPreview
+	for rows.Next() {
+		var i LinkPreview
+		if err := rows.Scan(
+			&i.Url,
+			&i.Status,
+			&i.Title,
+			&i.Description,
+			&i.ImageUrl,
+			&i.SiteName,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.FetchedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteChirpLinks = `-- name: DeleteChirpLinks :exec
+DELETE FROM chirp_links
+WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpLinks(ctx context.Context, chirpID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpLinks, chirpID)
+	return err
+}
+
+const getLinksForChirps = `-- name: GetLinksForChirps :many
+SELECT chirp_links.chirp_id, link_previews.url, link_previews.status, link_previews.title, link_previews.description, link_previews.image_url, link_previews.site_name, link_previews.attempts, link_previews.next_attempt_at, link_previews.last_error, link_previews.fetched_at, link_previews.created_at, link_previews.updated_at
+FROM chirp_links
+JOIN link_previews ON link_previews.url = chirp_links.url
+WHERE chirp_links.chirp_id = ANY($1::int[])
+ORDER BY chirp_links.chirp_id, chirp_links.position
+`
+
+type GetLinksForChirpsRow struct {
+	ChirpID     int32
+	LinkPreview LinkPreview
+}
+
+func (q *Queries) GetLinksForChirps(ctx context.Context, chirpIds []int32) ([]GetLinksForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLinksForChirps, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLinksForChirpsRow
+	for rows.Next() {
+		var i GetLinksForChirpsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.LinkPreview.Url,
+			&i.LinkPreview.Status,
+			&i.LinkPreview.Title,
+			&i.LinkPreview.Description,
+			&i.LinkPreview.ImageUrl,
+			&i.LinkPreview.SiteName,
+			&i.LinkPreview.Attempts,
+			&i.LinkPreview.NextAttemptAt,
+			&i.LinkPreview.LastError,
+			&i.LinkPreview.FetchedAt,
+			&i.LinkPreview.CreatedAt,
+			&i.LinkPreview.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markLinkPreviewFailed = `-- name: MarkLinkPreviewFailed :exec
+UPDATE link_previews SET
+    status = $1,
+    last_error = $2,
+    next_attempt_at = NOW() + ($3::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE url = $4
+`
+
+type MarkLinkPreviewFailedParams struct {
+	Status            string
+	LastError         sql.NullString
+	RetryAfterSeconds int32
+	Url               string
+}
+
+func (q *Queries) MarkLinkPreviewFailed(ctx context.Context, arg MarkLinkPreviewFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markLinkPreviewFailed,
+		arg.Status,
+		arg.LastError,
+		arg.RetryAfterSeconds,
+		arg.Url,
+	)
+	return err
+}
+
+const markLinkPreviewFetched = `-- name: MarkLinkPreviewFetched :exec
+UPDATE link_previews SET
+    status = 'fetched',
+    title = $2,
+    description = $3,
+    image_url = $4,
+    site_name = $5,
+    last_error = NULL,
+    fetched_at = NOW(),
+    updated_at = NOW()
+WHERE url = $1
+`
+
+type MarkLinkPreviewFetchedParams struct {
+	Url         string
+	Title       sql.NullString
+	Description sql.NullString
+	ImageUrl    sql.NullString
+	SiteName    sql.NullString
+}
+
+func (q *Queries) MarkLinkPreviewFetched(ctx context.Context, arg MarkLinkPreviewFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markLinkPreviewFetched,
+		arg.Url,
+		arg.Title,
+		arg.Description,
+		arg.ImageUrl,
+		arg.SiteName,
+	)
+	return err
+}
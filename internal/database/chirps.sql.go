@@ -7,23 +7,56 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countChirpsByAuthorID = `-- name: CountChirpsByAuthorID :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND hidden_at IS NULL AND deleted_at IS NULL AND publish_at IS NULL
+`
+
+func (q *Queries) CountChirpsByAuthorID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countChirpsByAuthorID, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRecentChirpsByAuthor = `-- name: CountRecentChirpsByAuthor :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND created_at > NOW() - INTERVAL '1 hour'
+`
+
+func (q *Queries) CountRecentChirpsByAuthor(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecentChirpsByAuthor, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (user_id, body)
-VALUES ($1, $2)
-RETURNING id, user_id, body, created_at, updated_at
+INSERT INTO chirps (user_id, body, publish_at, quote_of)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of
 `
 
 type CreateChirpParams struct {
-	UserID uuid.UUID
-	Body   string
+	UserID    uuid.UUID
+	Body      string
+	PublishAt sql.NullTime
+	QuoteOf   sql.NullInt32
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.UserID,
+		arg.Body,
+		arg.PublishAt,
+		arg.QuoteOf,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,12 +64,16 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.Body,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
 	)
 	return i, err
 }
 
 const deleteChirp = `-- name: DeleteChirp :exec
-DELETE FROM chirps WHERE id = $1
+UPDATE chirps SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteChirp(ctx context.Context, id int32) error {
@@ -44,12 +81,49 @@ func (q *Queries) DeleteChirp(ctx context.Context, id int32) error {
 	return err
 }
 
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND publish_at IS NOT NULL
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, user_id, body, created_at, updated_at FROM chirps
+SELECT chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = $1 AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $1 AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $2::int
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type GetAllChirpsParams struct {
+	ViewerID uuid.NullUUID
+	Limit    sql.NullInt32
+}
+
+// newest first; a NULL limit returns every chirp
+func (q *Queries) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, arg.ViewerID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +137,10 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.Body,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.QuoteOf,
 		); err != nil {
 			return nil, err
 		}
@@ -78,8 +156,8 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, user_id, body, created_at, updated_at FROM chirps
-WHERE id = $1
+SELECT id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id int32) (Chirp, error) {
@@ -91,17 +169,256 @@ func (q *Queries) GetChirpByID(ctx context.Context, id int32) (Chirp, error) {
 		&i.Body,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
 	)
 	return i, err
 }
 
 const getChirpsByAuthorID = `-- name: GetChirpsByAuthorID :many
-SELECT id, user_id, body, created_at, updated_at FROM chirps
-WHERE user_id = $1
+SELECT chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = $1 AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2 AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $3::int
+`
+
+type GetChirpsByAuthorIDParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+	Limit    sql.NullInt32
+}
+
+func (q *Queries) GetChirpsByAuthorID(ctx context.Context, arg GetChirpsByAuthorIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorID, arg.UserID, arg.ViewerID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirpByID = `-- name: GetDeletedChirpByID :one
+SELECT id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetDeletedChirpByID(ctx context.Context, id int32) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpByID, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const getQuotedChirps = `-- name: GetQuotedChirps :many
+SELECT chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.id = ANY($1::int[])
+    AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = $2 AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2 AND mutes.muted_id = chirps.user_id
+    )
+`
+
+type GetQuotedChirpsParams struct {
+	Ids      []int32
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetQuotedChirps(ctx context.Context, arg GetQuotedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getQuotedChirps, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirpsByAuthorID = `-- name: GetScheduledChirpsByAuthorID :many
+SELECT id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of FROM chirps
+WHERE user_id = $1 AND publish_at IS NOT NULL AND deleted_at IS NULL
+ORDER BY publish_at
+`
+
+func (q *Queries) GetScheduledChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsByAuthorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShareableChirpByID = `-- name: GetShareableChirpByID :one
+SELECT chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.id = $1 AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2
+    )
+`
+
+type GetShareableChirpByIDParams struct {
+	ID     int32
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetShareableChirpByID(ctx context.Context, arg GetShareableChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getShareableChirpByID, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const hideChirp = `-- name: HideChirp :one
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1
+RETURNING id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of
 `
 
-func (q *Queries) GetChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorID, userID)
+func (q *Queries) HideChirp(ctx context.Context, id int32) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, hideChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE publish_at IS NOT NULL AND publish_at <= NOW() AND deleted_at IS NULL
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE chirps SET publish_at = NULL, created_at = NOW(), updated_at = NOW()
+FROM due
+WHERE chirps.id = due.id
+RETURNING chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +432,10 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]
 			&i.Body,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.PublishAt,
+			&i.QuoteOf,
 		); err != nil {
 			return nil, err
 		}
@@ -128,3 +449,94 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]
 	}
 	return items, nil
 }
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < NOW() - ($1::int * INTERVAL '1 second')
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, windowSeconds int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, windowSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET hidden_at = NULL WHERE id = $1
+RETURNING id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id int32) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const restoreDeletedChirp = `-- name: RestoreDeletedChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = $1
+    AND deleted_at > NOW() - ($2::int * INTERVAL '1 second')
+RETURNING id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of
+`
+
+type RestoreDeletedChirpParams struct {
+	ID            int32
+	WindowSeconds int32
+}
+
+func (q *Queries) RestoreDeletedChirp(ctx context.Context, arg RestoreDeletedChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreDeletedChirp, arg.ID, arg.WindowSeconds)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const updateChirp = `-- name: UpdateChirp :one
+UPDATE chirps SET body = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, body, created_at, updated_at, hidden_at, deleted_at, publish_at, quote_of
+`
+
+type UpdateChirpParams struct {
+	ID   int32
+	Body string
+}
+
+func (q *Queries) UpdateChirp(ctx context.Context, arg UpdateChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirp, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.PublishAt,
+		&i.QuoteOf,
+	)
+	return i, err
+}
@@ -0,0 +1,289 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: conversations.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createConversation = `-- name: CreateConversation :one
+INSERT INTO conversations (id, user_a_id, user_b_id)
+VALUES (gen_random_uuid(), LEAST($1::uuid, $2::uuid), GREATEST($1::uuid, $2::uuid))
+ON CONFLICT (user_a_id, user_b_id) DO NOTHING
+RETURNING id, user_a_id, user_b_id, created_at, updated_at
+`
+
+type CreateConversationParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, createConversation, arg.UserID, arg.OtherID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.UserAID,
+		&i.UserBID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), $1, $2, $3)
+RETURNING id, conversation_id, sender_id, body, created_at, read_at
+`
+
+type CreateMessageParams struct {
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, createMessage, arg.ConversationID, arg.SenderID, arg.Body)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.SenderID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const getConversationBetween = `-- name: GetConversationBetween :one
+SELECT id, user_a_id, user_b_id, created_at, updated_at FROM conversations
+WHERE user_a_id = LEAST($1::uuid, $2::uuid)
+    AND user_b_id = GREATEST($1::uuid, $2::uuid)
+`
+
+type GetConversationBetweenParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) GetConversationBetween(ctx context.Context, arg GetConversationBetweenParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationBetween, arg.UserID, arg.OtherID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.UserAID,
+		&i.UserBID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getConversationForUser = `-- name: GetConversationForUser :one
+SELECT id, user_a_id, user_b_id, created_at, updated_at FROM conversations
+WHERE id = $1 AND (user_a_id = $2 OR user_b_id = $2)
+`
+
+type GetConversationForUserParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetConversationForUser(ctx context.Context, arg GetConversationForUserParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationForUser, arg.ID, arg.UserID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.UserAID,
+		&i.UserBID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getConversationsForUser = `-- name: GetConversationsForUser :many
+SELECT conversations.id, conversations.user_a_id, conversations.user_b_id, conversations.created_at, conversations.updated_at, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> $1
+        AND messages.read_at IS NULL
+)::bigint AS unread_count
+FROM conversations
+WHERE (conversations.user_a_id = $1 OR conversations.user_b_id = $1)
+    AND ($2::timestamp IS NULL
+        OR conversations.updated_at < $2
+        OR (conversations.updated_at = $2 AND conversations.id < $3::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT $4
+`
+
+type GetConversationsForUserParams struct {
+	UserID   uuid.UUID
+	Before   sql.NullTime
+	BeforeID uuid.NullUUID
+	Limit    int32
+}
+
+type GetConversationsForUserRow struct {
+	Conversation Conversation
+	UnreadCount  int64
+}
+
+func (q *Queries) GetConversationsForUser(ctx context.Context, arg GetConversationsForUserParams) ([]GetConversationsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getConversationsForUser,
+		arg.UserID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetConversationsForUserRow
+	for rows.Next() {
+		var i GetConversationsForUserRow
+		if err := rows.Scan(
+			&i.Conversation.ID,
+			&i.Conversation.UserAID,
+			&i.Conversation.UserBID,
+			&i.Conversation.CreatedAt,
+			&i.Conversation.UpdatedAt,
+			&i.UnreadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestMessages = `-- name: GetLatestMessages :many
+SELECT DISTINCT ON (conversation_id) id, conversation_id, sender_id, body, created_at, read_at FROM messages
+WHERE conversation_id = ANY($1::uuid[])
+ORDER BY conversation_id, created_at DESC, id DESC
+`
+
+func (q *Queries) GetLatestMessages(ctx context.Context, conversationIds []uuid.UUID) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, getLatestMessages, pq.Array(conversationIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessages = `-- name: GetMessages :many
+SELECT id, conversation_id, sender_id, body, created_at, read_at FROM messages
+WHERE conversation_id = $1
+    AND ($2::timestamp IS NULL
+        OR created_at < $2
+        OR (created_at = $2 AND id < $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type GetMessagesParams struct {
+	ConversationID uuid.UUID
+	Before         sql.NullTime
+	BeforeID       uuid.NullUUID
+	Limit          int32
+}
+
+func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, getMessages,
+		arg.ConversationID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markConversationRead = `-- name: MarkConversationRead :exec
+UPDATE messages SET read_at = NOW()
+WHERE conversation_id = $1
+    AND sender_id <> $2
+    AND read_at IS NULL
+`
+
+type MarkConversationReadParams struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+}
+
+func (q *Queries) MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) error {
+	_, err := q.db.ExecContext(ctx, markConversationRead, arg.ConversationID, arg.UserID)
+	return err
+}
+
+const touchConversation = `-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchConversation(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchConversation, id)
+	return err
+}
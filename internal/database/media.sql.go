@@ -0,0 +1,254 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: media.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const attachMediaToChirp = `-- name: AttachMediaToChirp :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES ($1, $2, $3)
+`
+
+type AttachMediaToChirpParams struct {
+	ChirpID  int32
+	MediaID  uuid.UUID
+	Position int32
+}
+
+func (q *Queries) AttachMediaToChirp(ctx context.Context, arg AttachMediaToChirpParams) error {
+	_, err := q.db.ExecContext(ctx, attachMediaToChirp, arg.ChirpID, arg.MediaID, arg.Position)
+	return err
+}
+
+const createMedia = `-- name: CreateMedia :one
+INSERT INTO media (id, user_id, content_type, width, height, size_bytes, storage_key, thumbnail_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, user_id, content_type, width, height, size_bytes, storage_key, thumbnail_key, created_at
+`
+
+type CreateMediaParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ContentType  string
+	Width        int32
+	Height       int32
+	SizeBytes    int32
+	StorageKey   string
+	ThumbnailKey string
+}
+
+func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Medium, error) {
+	row := q.db.QueryRowContext(ctx, createMedia,
+		arg.ID,
+		arg.UserID,
+		arg.ContentType,
+		arg.Width,
+		arg.Height,
+		arg.SizeBytes,
+		arg.StorageKey,
+		arg.ThumbnailKey,
+	)
+	var i Medium
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUnattachedMedia = `-- name: DeleteUnattachedMedia :many
+DELETE FROM media
+WHERE id IN (
+    SELECT m.id FROM media m
+    WHERE m.created_at < NOW() - ($1::int * INTERVAL '1 second')
+        AND NOT EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.media_id = m.id)
+    ORDER BY m.created_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING storage_key, thumbnail_key
+`
+
+type DeleteUnattachedMediaParams struct {
+	MinAgeSeconds int32
+	BatchSize     int32
+}
+
+type DeleteUnattachedMediaRow struct {
+	StorageKey   string
+	ThumbnailKey string
+}
+
+// uploads that were never attached to a chirp, or whose chirps were purged.
+// Media being attached right now is skipped, since attaching locks the row.
+func (q *Queries) DeleteUnattachedMedia(ctx context.Context, arg DeleteUnattachedMediaParams) ([]DeleteUnattachedMediaRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUnattachedMedia, arg.MinAgeSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteUnattachedMediaRow
+	for rows.Next() {
+		var i DeleteUnattachedMediaRow
+		if err := rows.Scan(&i.StorageKey, &i.ThumbnailKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMediaByID = `-- name: GetMediaByID :one
+SELECT id, user_id, content_type, width, height, size_bytes, storage_key, thumbnail_key, created_at FROM media WHERE id = $1
+`
+
+func (q *Queries) GetMediaByID(ctx context.Context, id uuid.UUID) (Medium, error) {
+	row := q.db.QueryRowContext(ctx, getMediaByID, id)
+	var i Medium
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMediaForChirps = `-- name: GetMediaForChirps :many
+SELECT chirp_media.chirp_id, media.id, media.user_id, media.content_type, media.width, media.height, media.size_bytes, media.storage_key, media.thumbnail_key, media.created_at
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY($1::int[])
+ORDER BY chirp_media.chirp_id, chirp_media.position
+`
+
+type GetMediaForChirpsRow struct {
+	ChirpID int32
+	Medium  Medium
+}
+
+func (q *Queries) GetMediaForChirps(ctx context.Context, chirpIds []int32) ([]GetMediaForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMediaForChirps, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMediaForChirpsRow
+	for rows.Next() {
+		var i GetMediaForChirpsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.Medium.ID,
+			&i.Medium.UserID,
+			&i.Medium.ContentType,
+			&i.Medium.Width,
+			&i.Medium.Height,
+			&i.Medium.SizeBytes,
+			&i.Medium.StorageKey,
+			&i.Medium.ThumbnailKey,
+			&i.Medium.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMediaUsage = `-- name: GetMediaUsage :one
+SELECT
+    COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 hour') AS recent_uploads,
+    COALESCE(SUM(size_bytes), 0)::bigint AS storage_bytes
+FROM media
+WHERE user_id = $1
+`
+
+type GetMediaUsageRow struct {
+	RecentUploads int64
+	StorageBytes  int64
+}
+
+func (q *Queries) GetMediaUsage(ctx context.Context, userID uuid.UUID) (GetMediaUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getMediaUsage, userID)
+	var i GetMediaUsageRow
+	err := row.Scan(&i.RecentUploads, &i.StorageBytes)
+	return i, err
+}
+
+const lockMediaOwnedByUser = `-- name: LockMediaOwnedByUser :many
+SELECT id FROM media
+WHERE user_id = $1 AND id = ANY($2::uuid[])
+FOR UPDATE
+`
+
+type LockMediaOwnedByUserParams struct {
+	UserID uuid.UUID
+	Ids    []uuid.UUID
+}
+
+// locks the media being attached, so DeleteUnattachedMedia can't remove it
+// before the chirp_media rows are committed
+func (q *Queries) LockMediaOwnedByUser(ctx context.Context, arg LockMediaOwnedByUserParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, lockMediaOwnedByUser, arg.UserID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserMediaUsage = `-- name: LockUserMediaUsage :exec
+SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE
+`
+
+// serializes a user's uploads, so each one's quota check sees the others
+func (q *Queries) LockUserMediaUsage(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockUserMediaUsage, id)
+	return err
+}
@@ -6,17 +6,211 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApDelivery struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Inbox          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type ApFollower struct {
+	UserID      uuid.UUID
+	ActorID     string
+	Inbox       string
+	SharedInbox sql.NullString
+	FollowID    string
+	CreatedAt   time.Time
+}
+
+type ApKey struct {
+	UserID     uuid.UUID
+	PrivateKey string
+	PublicKey  string
+	CreatedAt  time.Time
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   int32
+	CreatedAt time.Time
+}
+
 type Chirp struct {
 	ID        int32
 	UserID    uuid.UUID
 	Body      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	HiddenAt  sql.NullTime
+	DeletedAt sql.NullTime
+	PublishAt sql.NullTime
+	QuoteOf   sql.NullInt32
+}
+
+type ChirpEvent struct {
+	ID        int64
+	Type      string
+	ChirpID   int32
+	UserID    uuid.UUID
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	Seq       sql.NullInt64
+}
+
+type ChirpFlag struct {
+	ChirpID   int32
+	Terms     []string
+	CreatedAt time.Time
+}
+
+type ChirpLink struct {
+	ChirpID  int32
+	Position int32
+	Url      string
+}
+
+type ChirpMedium struct {
+	ChirpID  int32
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	UserAID   uuid.UUID
+	UserBID   uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Draft struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type LinkPreview struct {
+	Url           string
+	Status        string
+	Title         sql.NullString
+	Description   sql.NullString
+	ImageUrl      sql.NullString
+	SiteName      sql.NullString
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	FetchedAt     sql.NullTime
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ContentType  string
+	Width        int32
+	Height       int32
+	SizeBytes    int32
+	StorageKey   string
+	ThumbnailKey string
+	CreatedAt    time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+	ReadAt         sql.NullTime
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	ModeratorID uuid.NullUUID
+	Action      string
+	TargetType  string
+	TargetID    string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+type ModerationRule struct {
+	ID        uuid.UUID
+	Term      string
+	Action    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID          uuid.UUID
+	RecipientID uuid.UUID
+	Type        string
+	ChirpID     sql.NullInt32
+	ActorID     uuid.NullUUID
+	ActorCount  int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ReadAt      sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+}
+
+type Poll struct {
+	ChirpID  int32
+	ClosesAt time.Time
+}
+
+type PollOption struct {
+	ID       int32
+	ChirpID  int32
+	Position int32
+	Body     string
+}
+
+type PollVote struct {
+	ChirpID   int32
+	UserID    uuid.UUID
+	OptionID  int32
+	CreatedAt time.Time
+}
+
+type Rechirp struct {
+	UserID    uuid.UUID
+	ChirpID   int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +222,27 @@ type RefreshToken struct {
 	UpdatedAt time.Time
 }
 
+type Report struct {
+	ID         uuid.UUID
+	ChirpID    int32
+	ReporterID uuid.UUID
+	Reason     string
+	Status     string
+	ResolvedBy uuid.NullUUID
+	ResolvedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+type Subscription struct {
+	UserID           uuid.UUID
+	Plan             string
+	Status           string
+	CurrentPeriodEnd sql.NullTime
+	CancelledAt      sql.NullTime
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	Email          string
@@ -35,4 +250,53 @@ type User struct {
 	UpdatedAt      time.Time
 	HashedPassword string
 	IsChirpyRed    bool
+	IsModerator    bool
+	SuspendedUntil sql.NullTime
+	BannedAt       sql.NullTime
+	PinnedChirpID  sql.NullInt32
+	AvatarVersion  sql.NullString
+	DmPolicy       string
+	DmFilter       bool
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type WebhookEndpoint struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Url          string
+	Secret       string
+	Events       []string
+	Enabled      bool
+	FailureCount int32
+	DisabledAt   sql.NullTime
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type WebhookEvent struct {
+	ID          uuid.UUID
+	Provider    string
+	EventID     string
+	EventType   string
+	Payload     json.RawMessage
+	Status      string
+	Error       sql.NullString
+	Attempts    int32
+	ReceivedAt  time.Time
+	ProcessedAt sql.NullTime
+	UpdatedAt   time.Time
 }
@@ -0,0 +1,162 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: moderation.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const deleteChirpFlag = `-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpFlag(ctx context.Context, chirpID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChirpFlag, chirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteModerationRule = `-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules WHERE id = $1
+`
+
+func (q *Queries) DeleteModerationRule(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteModerationRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const flagChirp = `-- name: FlagChirp :exec
+INSERT INTO chirp_flags (chirp_id, terms)
+VALUES ($1, $2)
+ON CONFLICT (chirp_id) DO UPDATE SET terms = EXCLUDED.terms, created_at = NOW()
+`
+
+type FlagChirpParams struct {
+	ChirpID int32
+	Terms   []string
+}
+
+func (q *Queries) FlagChirp(ctx context.Context, arg FlagChirpParams) error {
+	_, err := q.db.ExecContext(ctx, flagChirp, arg.ChirpID, pq.Array(arg.Terms))
+	return err
+}
+
+const listFlaggedChirps = `-- name: ListFlaggedChirps :many
+SELECT chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of, chirp_flags.terms, chirp_flags.created_at AS flagged_at
+FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE chirps.deleted_at IS NULL
+ORDER BY chirp_flags.created_at
+`
+
+type ListFlaggedChirpsRow struct {
+	Chirp     Chirp
+	Terms     []string
+	FlaggedAt time.Time
+}
+
+func (q *Queries) ListFlaggedChirps(ctx context.Context) ([]ListFlaggedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFlaggedChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlaggedChirpsRow
+	for rows.Next() {
+		var i ListFlaggedChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PublishAt,
+			&i.Chirp.QuoteOf,
+			pq.Array(&i.Terms),
+			&i.FlaggedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationRules = `-- name: ListModerationRules :many
+SELECT id, term, action, created_at, updated_at FROM moderation_rules
+ORDER BY term
+`
+
+func (q *Queries) ListModerationRules(ctx context.Context) ([]ModerationRule, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ModerationRule
+	for rows.Next() {
+		var i ModerationRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Term,
+			&i.Action,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertModerationRule = `-- name: UpsertModerationRule :one
+INSERT INTO moderation_rules (id, term, action)
+VALUES (gen_random_uuid(), $1, $2)
+ON CONFLICT (term) DO UPDATE SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING id, term, action, created_at, updated_at
+`
+
+type UpsertModerationRuleParams struct {
+	Term   string
+	Action string
+}
+
+func (q *Queries) UpsertModerationRule(ctx context.Context, arg UpsertModerationRuleParams) (ModerationRule, error) {
+	row := q.db.QueryRowContext(ctx, upsertModerationRule, arg.Term, arg.Action)
+	var i ModerationRule
+	err := row.Scan(
+		&i.ID,
+		&i.Term,
+		&i.Action,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
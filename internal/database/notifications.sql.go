@@ -0,0 +1,187 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: notifications.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE recipient_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, recipientID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotifications, recipientID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+WITH eligible AS (
+    SELECT 1
+    WHERE $1::uuid <> $2::uuid
+        AND NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE blocks.blocker_id = $1 AND blocks.blocked_id = $2
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = $1 AND mutes.muted_id = $2
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM notification_actors
+            JOIN notifications ON notifications.id = notification_actors.notification_id
+            WHERE notifications.recipient_id = $1
+                AND notifications.type = $3
+                AND notifications.chirp_id = $4
+                AND notification_actors.actor_id = $2
+        )
+), notification AS (
+    INSERT INTO notifications (id, recipient_id, type, chirp_id, actor_id)
+    SELECT gen_random_uuid(), $1::uuid, $3::text, $4::int, $2::uuid
+    FROM eligible
+    ON CONFLICT (recipient_id, type, chirp_id) WHERE read_at IS NULL
+    DO UPDATE SET actor_id = EXCLUDED.actor_id,
+        actor_count = notifications.actor_count + 1,
+        updated_at = NOW()
+    RETURNING id
+), actor AS (
+    INSERT INTO notification_actors (notification_id, actor_id)
+    SELECT notification.id, $2::uuid FROM notification
+    ON CONFLICT DO NOTHING
+)
+SELECT pg_notify('notifications', notification.id::text) FROM notification
+`
+
+type CreateNotificationParams struct {
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	Type        string
+	ChirpID     sql.NullInt32
+}
+
+// listeners are told about new and coalesced notifications once the
+// surrounding transaction commits. An actor is only counted once per
+// recipient, type and chirp, read notifications included, so repeating an
+// action doesn't notify again.
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createNotification,
+		arg.RecipientID,
+		arg.ActorID,
+		arg.Type,
+		arg.ChirpID,
+	)
+	return err
+}
+
+const getNotificationByID = `-- name: GetNotificationByID :one
+SELECT id, recipient_id, type, chirp_id, actor_id, actor_count, created_at, updated_at, read_at FROM notifications WHERE id = $1
+`
+
+func (q *Queries) GetNotificationByID(ctx context.Context, id uuid.UUID) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationByID, id)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.RecipientID,
+		&i.Type,
+		&i.ChirpID,
+		&i.ActorID,
+		&i.ActorCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const getNotificationsByRecipient = `-- name: GetNotificationsByRecipient :many
+SELECT id, recipient_id, type, chirp_id, actor_id, actor_count, created_at, updated_at, read_at FROM notifications
+WHERE recipient_id = $1
+    AND ($2::timestamp IS NULL
+        OR updated_at < $2
+        OR (updated_at = $2 AND id < $3::uuid))
+ORDER BY updated_at DESC, id DESC
+LIMIT $4
+`
+
+type GetNotificationsByRecipientParams struct {
+	RecipientID uuid.UUID
+	Before      sql.NullTime
+	BeforeID    uuid.NullUUID
+	Limit       int32
+}
+
+func (q *Queries) GetNotificationsByRecipient(ctx context.Context, arg GetNotificationsByRecipientParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationsByRecipient,
+		arg.RecipientID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.RecipientID,
+			&i.Type,
+			&i.ChirpID,
+			&i.ActorID,
+			&i.ActorCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE recipient_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, recipientID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markAllNotificationsRead, recipientID)
+	return err
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND recipient_id = $2
+`
+
+type MarkNotificationReadParams struct {
+	ID          uuid.UUID
+	RecipientID uuid.UUID
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationRead, arg.ID, arg.RecipientID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
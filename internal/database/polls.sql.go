@@ -0,0 +1,188 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPoll = `-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, closes_at)
+VALUES ($1, $2)
+`
+
+type CreatePollParams struct {
+	ChirpID  int32
+	ClosesAt time.Time
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) error {
+	_, err := q.db.ExecContext(ctx, createPoll, arg.ChirpID, arg.ClosesAt)
+	return err
+}
+
+const createPollOption = `-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, body)
+VALUES ($1, $2, $3)
+`
+
+type CreatePollOptionParams struct {
+	ChirpID  int32
+	Position int32
+	Body     string
+}
+
+func (q *Queries) CreatePollOption(ctx context.Context, arg CreatePollOptionParams) error {
+	_, err := q.db.ExecContext(ctx, createPollOption, arg.ChirpID, arg.Position, arg.Body)
+	return err
+}
+
+const createPollVote = `-- name: CreatePollVote :one
+INSERT INTO poll_votes (chirp_id, user_id, option_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (chirp_id, user_id) DO NOTHING
+RETURNING chirp_id, user_id, option_id, created_at
+`
+
+type CreatePollVoteParams struct {
+	ChirpID  int32
+	UserID   uuid.UUID
+	OptionID int32
+}
+
+func (q *Queries) CreatePollVote(ctx context.Context, arg CreatePollVoteParams) (PollVote, error) {
+	row := q.db.QueryRowContext(ctx, createPollVote, arg.ChirpID, arg.UserID, arg.OptionID)
+	var i PollVote
+	err := row.Scan(
+		&i.ChirpID,
+		&i.UserID,
+		&i.OptionID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPollByChirpID = `-- name: GetPollByChirpID :one
+SELECT chirp_id, closes_at FROM polls WHERE chirp_id = $1
+`
+
+func (q *Queries) GetPollByChirpID(ctx context.Context, chirpID int32) (Poll, error) {
+	row := q.db.QueryRowContext(ctx, getPollByChirpID, chirpID)
+	var i Poll
+	err := row.Scan(&i.ChirpID, &i.ClosesAt)
+	return i, err
+}
+
+const getPollOptionByID = `-- name: GetPollOptionByID :one
+SELECT id, chirp_id, position, body FROM poll_options WHERE id = $1 AND chirp_id = $2
+`
+
+type GetPollOptionByIDParams struct {
+	ID      int32
+	ChirpID int32
+}
+
+func (q *Queries) GetPollOptionByID(ctx context.Context, arg GetPollOptionByIDParams) (PollOption, error) {
+	row := q.db.QueryRowContext(ctx, getPollOptionByID, arg.ID, arg.ChirpID)
+	var i PollOption
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.Position,
+		&i.Body,
+	)
+	return i, err
+}
+
+const getPollTallies = `-- name: GetPollTallies :many
+SELECT poll_options.id, poll_options.chirp_id, poll_options.body, polls.closes_at,
+    COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+JOIN polls ON polls.chirp_id = poll_options.chirp_id
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY($1::int[])
+GROUP BY poll_options.id, polls.closes_at
+ORDER BY poll_options.chirp_id, poll_options.position
+`
+
+type GetPollTalliesRow struct {
+	ID       int32
+	ChirpID  int32
+	Body     string
+	ClosesAt time.Time
+	Votes    int64
+}
+
+func (q *Queries) GetPollTallies(ctx context.Context, chirpIds []int32) ([]GetPollTalliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollTallies, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollTalliesRow
+	for rows.Next() {
+		var i GetPollTalliesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.ClosesAt,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPollVotesByUser = `-- name: GetPollVotesByUser :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = $1 AND chirp_id = ANY($2::int[])
+`
+
+type GetPollVotesByUserParams struct {
+	UserID   uuid.UUID
+	ChirpIds []int32
+}
+
+type GetPollVotesByUserRow struct {
+	ChirpID  int32
+	OptionID int32
+}
+
+func (q *Queries) GetPollVotesByUser(ctx context.Context, arg GetPollVotesByUserParams) ([]GetPollVotesByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollVotesByUser, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollVotesByUserRow
+	for rows.Next() {
+		var i GetPollVotesByUserRow
+		if err := rows.Scan(&i.ChirpID, &i.OptionID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,84 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: rechirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getRechirpCounts = `-- name: GetRechirpCounts :many
+SELECT chirp_id, COUNT(*) AS rechirp_count
+FROM rechirps
+WHERE chirp_id = ANY($1::int[])
+GROUP BY chirp_id
+`
+
+type GetRechirpCountsRow struct {
+	ChirpID      int32
+	RechirpCount int64
+}
+
+func (q *Queries) GetRechirpCounts(ctx context.Context, chirpIds []int32) ([]GetRechirpCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRechirpCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRechirpCountsRow
+	for rows.Next() {
+		var i GetRechirpCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rechirp = `-- name: Rechirp :execrows
+INSERT INTO rechirps (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type RechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID int32
+}
+
+func (q *Queries) Rechirp(ctx context.Context, arg RechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rechirp, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unrechirp = `-- name: Unrechirp :execrows
+DELETE FROM rechirps WHERE user_id = $1 AND chirp_id = $2
+`
+
+type UnrechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID int32
+}
+
+func (q *Queries) Unrechirp(ctx context.Context, arg UnrechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unrechirp, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
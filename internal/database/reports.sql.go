@@ -0,0 +1,234 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: reports.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createReport = `-- name: CreateReport :one
+INSERT INTO reports (id, chirp_id, reporter_id, reason)
+VALUES (gen_random_uuid(), $1, $2, $3)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING id, chirp_id, reporter_id, reason, status, resolved_by, resolved_at, created_at
+`
+
+type CreateReportParams struct {
+	ChirpID    int32
+	ReporterID uuid.UUID
+	Reason     string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, createReport, arg.ChirpID, arg.ReporterID, arg.Reason)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Status,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReportByID = `-- name: GetReportByID :one
+SELECT id, chirp_id, reporter_id, reason, status, resolved_by, resolved_at, created_at FROM reports WHERE id = $1
+`
+
+func (q *Queries) GetReportByID(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.db.QueryRowContext(ctx, getReportByID, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Status,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listModerationActions = `-- name: ListModerationActions :many
+SELECT id, moderator_id, action, target_type, target_id, reason, created_at FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT $1
+`
+
+func (q *Queries) ListModerationActions(ctx context.Context, limit int32) ([]ModerationAction, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationActions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ModerationAction
+	for rows.Next() {
+		var i ModerationAction
+		if err := rows.Scan(
+			&i.ID,
+			&i.ModeratorID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenReports = `-- name: ListOpenReports :many
+SELECT reports.id, reports.chirp_id, reports.reporter_id, reports.reason, reports.status, reports.resolved_by, reports.resolved_at, reports.created_at, chirps.id, chirps.user_id, chirps.body, chirps.created_at, chirps.updated_at, chirps.hidden_at, chirps.deleted_at, chirps.publish_at, chirps.quote_of
+FROM reports
+JOIN chirps ON chirps.id = reports.chirp_id
+WHERE reports.status = 'open' AND chirps.deleted_at IS NULL
+ORDER BY reports.created_at
+`
+
+type ListOpenReportsRow struct {
+	Report Report
+	Chirp  Chirp
+}
+
+func (q *Queries) ListOpenReports(ctx context.Context) ([]ListOpenReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenReports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenReportsRow
+	for rows.Next() {
+		var i ListOpenReportsRow
+		if err := rows.Scan(
+			&i.Report.ID,
+			&i.Report.ChirpID,
+			&i.Report.ReporterID,
+			&i.Report.Reason,
+			&i.Report.Status,
+			&i.Report.ResolvedBy,
+			&i.Report.ResolvedAt,
+			&i.Report.CreatedAt,
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.PublishAt,
+			&i.Chirp.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordModerationAction = `-- name: RecordModerationAction :one
+INSERT INTO moderation_actions (id, moderator_id, action, target_type, target_id, reason)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5)
+RETURNING id, moderator_id, action, target_type, target_id, reason, created_at
+`
+
+type RecordModerationActionParams struct {
+	ModeratorID uuid.NullUUID
+	Action      string
+	TargetType  string
+	TargetID    string
+	Reason      string
+}
+
+func (q *Queries) RecordModerationAction(ctx context.Context, arg RecordModerationActionParams) (ModerationAction, error) {
+	row := q.db.QueryRowContext(ctx, recordModerationAction,
+		arg.ModeratorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Reason,
+	)
+	var i ModerationAction
+	err := row.Scan(
+		&i.ID,
+		&i.ModeratorID,
+		&i.Action,
+		&i.TargetType,
+		&i.TargetID,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const resolveReport = `-- name: ResolveReport :one
+UPDATE reports SET status = $2, resolved_by = $3, resolved_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING id, chirp_id, reporter_id, reason, status, resolved_by, resolved_at, created_at
+`
+
+type ResolveReportParams struct {
+	ID         uuid.UUID
+	Status     string
+	ResolvedBy uuid.NullUUID
+}
+
+func (q *Queries) ResolveReport(ctx context.Context, arg ResolveReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, resolveReport, arg.ID, arg.Status, arg.ResolvedBy)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Status,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const resolveReportsForChirp = `-- name: ResolveReportsForChirp :execrows
+UPDATE reports SET status = $2, resolved_by = $3, resolved_at = NOW()
+WHERE chirp_id = $1 AND status = 'open'
+`
+
+type ResolveReportsForChirpParams struct {
+	ChirpID    int32
+	Status     string
+	ResolvedBy uuid.NullUUID
+}
+
+func (q *Queries) ResolveReportsForChirp(ctx context.Context, arg ResolveReportsForChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveReportsForChirp, arg.ChirpID, arg.Status, arg.ResolvedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,141 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: subscriptions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const cancelSubscription = `-- name: CancelSubscription :one
+UPDATE subscriptions SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND status IN ('active', 'past_due')
+RETURNING user_id, plan, status, current_period_end, cancelled_at, created_at, updated_at
+`
+
+func (q *Queries) CancelSubscription(ctx context.Context, userID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, cancelSubscription, userID)
+	var i Subscription
+	err := row.Scan(
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const endSubscription = `-- name: EndSubscription :exec
+UPDATE subscriptions SET status = 'expired', current_period_end = LEAST(current_period_end, NOW()), updated_at = NOW()
+WHERE user_id = $1 AND status <> 'expired'
+`
+
+func (q *Queries) EndSubscription(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, endSubscription, userID)
+	return err
+}
+
+const expireSubscriptions = `-- name: ExpireSubscriptions :execrows
+WITH expired AS (
+    UPDATE subscriptions SET status = 'expired', updated_at = NOW()
+    WHERE status <> 'expired' AND current_period_end <= NOW()
+    RETURNING user_id
+)
+UPDATE users SET is_chirpy_red = FALSE, updated_at = NOW()
+WHERE id IN (SELECT user_id FROM expired)
+`
+
+func (q *Queries) ExpireSubscriptions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireSubscriptions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getSubscriptionByUserID = `-- name: GetSubscriptionByUserID :one
+SELECT user_id, plan, status, current_period_end, cancelled_at, created_at, updated_at FROM subscriptions WHERE user_id = $1
+`
+
+func (q *Queries) GetSubscriptionByUserID(ctx context.Context, userID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getSubscriptionByUserID, userID)
+	var i Subscription
+	err := row.Scan(
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const markSubscriptionPastDue = `-- name: MarkSubscriptionPastDue :one
+UPDATE subscriptions SET status = 'past_due', updated_at = NOW()
+WHERE user_id = $1 AND status = 'active'
+RETURNING user_id, plan, status, current_period_end, cancelled_at, created_at, updated_at
+`
+
+func (q *Queries) MarkSubscriptionPastDue(ctx context.Context, userID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, markSubscriptionPastDue, userID)
+	var i Subscription
+	err := row.Scan(
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertSubscription = `-- name: UpsertSubscription :one
+INSERT INTO subscriptions (user_id, plan, status, current_period_end)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE SET
+    plan = EXCLUDED.plan,
+    status = EXCLUDED.status,
+    current_period_end = EXCLUDED.current_period_end,
+    cancelled_at = NULL,
+    updated_at = NOW()
+RETURNING user_id, plan, status, current_period_end, cancelled_at, created_at, updated_at
+`
+
+type UpsertSubscriptionParams struct {
+	UserID           uuid.UUID
+	Plan             string
+	Status           string
+	CurrentPeriodEnd sql.NullTime
+}
+
+func (q *Queries) UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertSubscription,
+		arg.UserID,
+		arg.Plan,
+		arg.Status,
+		arg.CurrentPeriodEnd,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
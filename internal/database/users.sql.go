@@ -7,14 +7,41 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :one
+UPDATE users SET banned_at = NOW(), updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+func (q *Queries) BanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, banUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, email, hashed_password)
 VALUES (gen_random_uuid(), $1, $2)
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
 `
 
 type CreateUserParams struct {
@@ -32,6 +59,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
 	)
 	return i, err
 }
@@ -45,8 +79,57 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const downgradeUser = `-- name: DowngradeUser :one
+UPDATE users SET is_chirpy_red = FALSE, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+func (q *Queries) DowngradeUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, downgradeUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const getPinnedChirpID = `-- name: GetPinnedChirpID :one
+SELECT pinned_chirp_id FROM users WHERE id = $1
+`
+
+func (q *Queries) GetPinnedChirpID(ctx context.Context, id uuid.UUID) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, getPinnedChirpID, id)
+	var pinned_chirp_id sql.NullInt32
+	err := row.Scan(&pinned_chirp_id)
+	return pinned_chirp_id, err
+}
+
+const getUserAvatarVersionForUpdate = `-- name: GetUserAvatarVersionForUpdate :one
+SELECT avatar_version FROM users WHERE id = $1 FOR UPDATE
+`
+
+// locks the user so avatar changes are serialized
+func (q *Queries) GetUserAvatarVersionForUpdate(ctx context.Context, id uuid.UUID) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getUserAvatarVersionForUpdate, id)
+	var avatar_version sql.NullString
+	err := row.Scan(&avatar_version)
+	return avatar_version, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -59,13 +142,210 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const getUserPlan = `-- name: GetUserPlan :one
+SELECT (CASE WHEN users.is_chirpy_red THEN COALESCE(subscriptions.plan, 'red') ELSE 'free' END)::text AS plan
+FROM users
+LEFT JOIN subscriptions ON subscriptions.user_id = users.id
+WHERE users.id = $1
+`
+
+func (q *Queries) GetUserPlan(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserPlan, id)
+	var plan string
+	err := row.Scan(&plan)
+	return plan, err
+}
+
+const pinChirp = `-- name: PinChirp :exec
+UPDATE users SET pinned_chirp_id = $2, updated_at = NOW() WHERE id = $1
+`
+
+type PinChirpParams struct {
+	ID            uuid.UUID
+	PinnedChirpID sql.NullInt32
+}
+
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) error {
+	_, err := q.db.ExecContext(ctx, pinChirp, arg.ID, arg.PinnedChirpID)
+	return err
+}
+
+const reinstateUser = `-- name: ReinstateUser :one
+UPDATE users SET banned_at = NULL, suspended_until = NULL, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+func (q *Queries) ReinstateUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, reinstateUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
 	)
 	return i, err
 }
 
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users SET avatar_version = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+type SetUserAvatarParams struct {
+	ID            uuid.UUID
+	AvatarVersion sql.NullString
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatar, arg.ID, arg.AvatarVersion)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const setUserModerator = `-- name: SetUserModerator :one
+UPDATE users SET is_moderator = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+type SetUserModeratorParams struct {
+	ID          uuid.UUID
+	IsModerator bool
+}
+
+func (q *Queries) SetUserModerator(ctx context.Context, arg SetUserModeratorParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserModerator, arg.ID, arg.IsModerator)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users SET suspended_until = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+type SuspendUserParams struct {
+	ID             uuid.UUID
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const unpinChirp = `-- name: UnpinChirp :execrows
+UPDATE users SET pinned_chirp_id = NULL, updated_at = NOW()
+WHERE id = $1 AND pinned_chirp_id = $2
+`
+
+type UnpinChirpParams struct {
+	ID            uuid.UUID
+	PinnedChirpID sql.NullInt32
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpinChirp, arg.ID, arg.PinnedChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users SET email = $2, hashed_password = $3 WHERE id = $1
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
 `
 
 type UpdateUserParams struct {
@@ -84,13 +364,52 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
+	)
+	return i, err
+}
+
+const updateUserDMSettings = `-- name: UpdateUserDMSettings :one
+UPDATE users SET dm_policy = $2, dm_filter = $3, updated_at = NOW() WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
+`
+
+type UpdateUserDMSettingsParams struct {
+	ID       uuid.UUID
+	DmPolicy string
+	DmFilter bool
+}
+
+func (q *Queries) UpdateUserDMSettings(ctx context.Context, arg UpdateUserDMSettingsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserDMSettings, arg.ID, arg.DmPolicy, arg.DmFilter)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
 	)
 	return i, err
 }
 
 const upgradeUser = `-- name: UpgradeUser :one
 UPDATE users SET is_chirpy_red = TRUE, updated_at = NOW() WHERE id = $1
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, is_moderator, suspended_until, banned_at, pinned_chirp_id, avatar_version, dm_policy, dm_filter
 `
 
 func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -103,6 +422,13 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsModerator,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PinnedChirpID,
+		&i.AvatarVersion,
+		&i.DmPolicy,
+		&i.DmFilter,
 	)
 	return i, err
 }
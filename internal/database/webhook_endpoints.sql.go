@@ -0,0 +1,190 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhook_endpoints.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, user_id, url, secret, events)
+VALUES (gen_random_uuid(), $1, $2, $3, $4)
+RETURNING id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at
+`
+
+type CreateWebhookEndpointParams struct {
+	UserID uuid.UUID
+	Url    string
+	Secret string
+	Events []string
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookEndpoint,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		pq.Array(arg.Events),
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookEndpoint = `-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhookEndpoint, id)
+	return err
+}
+
+const enableWebhookEndpoint = `-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints SET enabled = TRUE, failure_count = 0, disabled_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at
+`
+
+func (q *Queries) EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	row := q.db.QueryRowContext(ctx, enableWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookEndpointByID = `-- name: GetWebhookEndpointByID :one
+SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at FROM webhook_endpoints WHERE id = $1
+`
+
+func (q *Queries) GetWebhookEndpointByID(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEndpointByID, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookEndpointsByUserID = `-- name: GetWebhookEndpointsByUserID :many
+SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetWebhookEndpointsByUserID(ctx context.Context, userID uuid.UUID) ([]WebhookEndpoint, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhookEndpointsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookEndpoint
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			pq.Array(&i.Events),
+			&i.Enabled,
+			&i.FailureCount,
+			&i.DisabledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordWebhookEndpointFailure = `-- name: RecordWebhookEndpointFailure :one
+UPDATE webhook_endpoints SET
+    failure_count = failure_count + 1,
+    enabled = enabled AND failure_count + 1 < $1::int,
+    disabled_at = CASE
+        WHEN enabled AND failure_count + 1 >= $1::int THEN NOW()
+        ELSE disabled_at
+    END,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, user_id, url, secret, events, enabled, failure_count, disabled_at, created_at, updated_at
+`
+
+type RecordWebhookEndpointFailureParams struct {
+	MaxFailures int32
+	ID          uuid.UUID
+}
+
+func (q *Queries) RecordWebhookEndpointFailure(ctx context.Context, arg RecordWebhookEndpointFailureParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRowContext(ctx, recordWebhookEndpointFailure, arg.MaxFailures, arg.ID)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const resetWebhookEndpointFailures = `-- name: ResetWebhookEndpointFailures :exec
+UPDATE webhook_endpoints SET failure_count = 0, updated_at = NOW()
+WHERE id = $1 AND failure_count > 0
+`
+
+func (q *Queries) ResetWebhookEndpointFailures(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resetWebhookEndpointFailures, id)
+	return err
+}
@@ -0,0 +1,211 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhook_events.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const claimWebhookEvent = `-- name: ClaimWebhookEvent :one
+UPDATE webhook_events SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+    AND (
+        status IN ('pending', 'failed')
+        OR (status = 'processing' AND updated_at < NOW() - ($2::int * INTERVAL '1 second'))
+    )
+RETURNING id, provider, event_id, event_type, payload, status, error, attempts, received_at, processed_at, updated_at
+`
+
+type ClaimWebhookEventParams struct {
+	ID           uuid.UUID
+	LeaseSeconds int32
+}
+
+// a processing event whose lease has run out was abandoned by a crashed or
+// timed out attempt, and can be claimed again
+func (q *Queries) ClaimWebhookEvent(ctx context.Context, arg ClaimWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, claimWebhookEvent, arg.ID, arg.LeaseSeconds)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Error,
+		&i.Attempts,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookEvent = `-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (id, provider, event_id, event_type, payload)
+VALUES (gen_random_uuid(), $1, $2, $3, $4)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING id, provider, event_id, event_type, payload, status, error, attempts, received_at, processed_at, updated_at
+`
+
+type CreateWebhookEventParams struct {
+	Provider  string
+	EventID   string
+	EventType string
+	Payload   json.RawMessage
+}
+
+func (q *Queries) CreateWebhookEvent(ctx context.Context, arg CreateWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookEvent,
+		arg.Provider,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Error,
+		&i.Attempts,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const finishWebhookEvent = `-- name: FinishWebhookEvent :exec
+UPDATE webhook_events SET status = $2, error = $3, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND attempts = $4
+`
+
+type FinishWebhookEventParams struct {
+	ID       uuid.UUID
+	Status   string
+	Error    sql.NullString
+	Attempts int32
+}
+
+// only the latest claim records an outcome
+func (q *Queries) FinishWebhookEvent(ctx context.Context, arg FinishWebhookEventParams) error {
+	_, err := q.db.ExecContext(ctx, finishWebhookEvent,
+		arg.ID,
+		arg.Status,
+		arg.Error,
+		arg.Attempts,
+	)
+	return err
+}
+
+const getWebhookEventByID = `-- name: GetWebhookEventByID :one
+SELECT id, provider, event_id, event_type, payload, status, error, attempts, received_at, processed_at, updated_at FROM webhook_events
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookEventByID(ctx context.Context, id uuid.UUID) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEventByID, id)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Error,
+		&i.Attempts,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookEventByProviderID = `-- name: GetWebhookEventByProviderID :one
+SELECT id, provider, event_id, event_type, payload, status, error, attempts, received_at, processed_at, updated_at FROM webhook_events
+WHERE provider = $1 AND event_id = $2
+`
+
+type GetWebhookEventByProviderIDParams struct {
+	Provider string
+	EventID  string
+}
+
+func (q *Queries) GetWebhookEventByProviderID(ctx context.Context, arg GetWebhookEventByProviderIDParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEventByProviderID, arg.Provider, arg.EventID)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Error,
+		&i.Attempts,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listWebhookEvents = `-- name: ListWebhookEvents :many
+SELECT id, provider, event_id, event_type, payload, status, error, attempts, received_at, processed_at, updated_at FROM webhook_events
+WHERE $1::text IS NULL OR status = $1::text
+ORDER BY received_at DESC
+LIMIT $2
+`
+
+type ListWebhookEventsParams struct {
+	Status sql.NullString
+	Limit  int32
+}
+
+func (q *Queries) ListWebhookEvents(ctx context.Context, arg ListWebhookEventsParams) ([]WebhookEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookEvents, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookEvent
+	for rows.Next() {
+		var i WebhookEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Provider,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Error,
+			&i.Attempts,
+			&i.ReceivedAt,
+			&i.ProcessedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,87 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	PlanFree = "free"
+	PlanRed  = "red"
+)
+
+// Limits describes what a user on a plan is allowed to do. A zero
+// ChirpsPerHour or MediaUploadsPerHour means that isn't rate limited, and a
+// zero MediaStorageBytes means stored media isn't capped.
+type Limits struct {
+	MaxChirpLength      int   `json:"max_chirp_length"`
+	CanEditChirps       bool  `json:"can_edit_chirps"`
+	CanScheduleChirps   bool  `json:"can_schedule_chirps"`
+	ChirpsPerHour       int   `json:"chirps_per_hour"`
+	MediaUploadsPerHour int   `json:"media_uploads_per_hour"`
+	MediaStorageBytes   int64 `json:"media_storage_bytes"`
+}
+
+// Plans maps a plan name to its limits.
+type Plans map[string]Limits
+
+func DefaultPlans() Plans {
+	return Plans{
+		PlanFree: {
+			MaxChirpLength:      140,
+			ChirpsPerHour:       30,
+			MediaUploadsPerHour: 20,
+			MediaStorageBytes:   100 << 20,
+		},
+		PlanRed: {
+			MaxChirpLength:      280,
+			CanEditChirps:       true,
+			CanScheduleChirps:   true,
+			ChirpsPerHour:       300,
+			MediaUploadsPerHour: 100,
+			MediaStorageBytes:   1 << 30,
+		},
+	}
+}
+
+// LoadPlans reads plan limits from a JSON file keyed by plan name. The file
+// must define the free plan, which is used for any plan it doesn't list.
+func LoadPlans(path string) (Plans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := Plans{}
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("error parsing plans: %w", err)
+	}
+	if _, ok := plans[PlanFree]; !ok {
+		return nil, fmt.Errorf("plans must define the %q plan", PlanFree)
+	}
+	for name, limits := range plans {
+		if limits.MaxChirpLength <= 0 {
+			return nil, fmt.Errorf("plan %q: max_chirp_length must be positive", name)
+		}
+		if limits.ChirpsPerHour < 0 {
+			return nil, fmt.Errorf("plan %q: chirps_per_hour must not be negative", name)
+		}
+		if limits.MediaUploadsPerHour < 0 {
+			return nil, fmt.Errorf("plan %q: media_uploads_per_hour must not be negative", name)
+		}
+		if limits.MediaStorageBytes < 0 {
+			return nil, fmt.Errorf("plan %q: media_storage_bytes must not be negative", name)
+		}
+	}
+
+	return plans, nil
+}
+
+// For returns the limits for plan, falling back to the free plan.
+func (p Plans) For(plan string) Limits {
+	if limits, ok := p[plan]; ok {
+		return limits
+	}
+	return p[PlanFree]
+}
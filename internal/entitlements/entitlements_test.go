@@ -0,0 +1,79 @@
+package entitlements
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePlans(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plans.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Error writing plans file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPlans(t *testing.T) {
+	path := writePlans(t, `{
+		"free": {"max_chirp_length": 100, "chirps_per_hour": 10, "media_uploads_per_hour": 5, "media_storage_bytes": 1048576},
+		"red": {"max_chirp_length": 500, "can_edit_chirps": true}
+	}`)
+
+	plans, err := LoadPlans(path)
+	if err != nil {
+		t.Fatalf("LoadPlans returned error: %v", err)
+	}
+
+	free := plans.For(PlanFree)
+	if free.MediaUploadsPerHour != 5 || free.MediaStorageBytes != 1<<20 {
+		t.Errorf("unexpected free media limits: %+v", free)
+	}
+
+	red := plans.For(PlanRed)
+	if red.MaxChirpLength != 500 || !red.CanEditChirps || red.CanScheduleChirps {
+		t.Errorf("unexpected red limits: %+v", red)
+	}
+	if red.ChirpsPerHour != 0 {
+		t.Errorf("red ChirpsPerHour = %d, want 0 (unlimited)", red.ChirpsPerHour)
+	}
+}
+
+func TestLoadPlansRequiresFree(t *testing.T) {
+	path := writePlans(t, `{"red": {"max_chirp_length": 500}}`)
+
+	_, err := LoadPlans(path)
+	if err == nil {
+		t.Fatal("Expected error for plans without a free plan")
+	}
+}
+
+func TestLoadPlansRejectsInvalidLength(t *testing.T) {
+	path := writePlans(t, `{"free": {"max_chirp_length": 0}}`)
+
+	_, err := LoadPlans(path)
+	if err == nil {
+		t.Fatal("Expected error for zero max_chirp_length")
+	}
+}
+
+func TestLoadPlansRejectsNegativeMediaLimits(t *testing.T) {
+	for _, contents := range []string{
+		`{"free": {"max_chirp_length": 140, "media_uploads_per_hour": -1}}`,
+		`{"free": {"max_chirp_length": 140, "media_storage_bytes": -1}}`,
+	} {
+		if _, err := LoadPlans(writePlans(t, contents)); err == nil {
+			t.Errorf("Expected error for %s", contents)
+		}
+	}
+}
+
+func TestPlansForUnknownPlan(t *testing.T) {
+	plans := DefaultPlans()
+
+	got := plans.For("platinum")
+	if got != plans[PlanFree] {
+		t.Errorf("For(unknown) = %+v, want free plan %+v", got, plans[PlanFree])
+	}
+}
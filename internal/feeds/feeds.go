@@ -0,0 +1,189 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+
+	atomNamespace = "http://www.w3.org/2005/Atom"
+
+	// titleLength is how many characters of a chirp are used as its entry
+	// title, since chirps don't have one of their own
+	titleLength = 60
+)
+
+// Feed is a feed of chirps, rendered as Atom or RSS 2.0. Links must be
+// absolute URLs; IDs should be too, since readers use them to recognise
+// entries they have already seen.
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string
+	SelfLink    string
+	Updated     time.Time
+	Entries     []Entry
+}
+
+type Entry struct {
+	ID         string
+	Link       string
+	AuthorName string
+	AuthorURI  string
+	Content    string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Title shortens body to a one-line entry title.
+func Title(body string) string {
+	title := strings.Join(strings.FieldsFunc(body, unicode.IsSpace), " ")
+	runes := []rune(title)
+	if len(runes) <= titleLength {
+		return title
+	}
+	return strings.TrimRightFunc(string(runes[:titleLength-1]), unicode.IsSpace) + "…"
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+	Author    atomPerson `xml:"author"`
+	Content   atomText   `xml:"content"`
+}
+
+// Atom renders the feed as an Atom 1.0 document.
+func (f Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		XMLNS:    atomNamespace,
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfLink},
+			{Rel: "alternate", Href: f.Link},
+		},
+	}
+	for _, entry := range f.Entries {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        entry.ID,
+			Title:     atomText{Type: "text", Body: Title(entry.Content)},
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Updated:   entry.Updated.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Rel: "alternate", Href: entry.Link}},
+			Author:    atomPerson{Name: entry.AuthorName, URI: entry.AuthorURI},
+			Content:   atomText{Type: "text", Body: entry.Content},
+		})
+	}
+	return marshal(doc)
+}
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXMLNS string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// RSS renders the feed as an RSS 2.0 document. Entry IDs are used as guids
+// and marked as permalinks when they equal the entry's link. Readers treat
+// item descriptions as HTML, so the content is HTML-escaped before it is
+// XML-escaped.
+func (f Feed) RSS() ([]byte, error) {
+	doc := rssDocument{
+		Version:   "2.0",
+		AtomXMLNS: atomNamespace,
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+			AtomLink:    atomLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfLink},
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, entry := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       Title(entry.Content),
+			Link:        entry.Link,
+			Description: html.EscapeString(entry.Content),
+			GUID:        rssGUID{IsPermaLink: entry.ID == entry.Link, Value: entry.ID},
+			PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshal(doc)
+}
+
+func marshal(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,114 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)
+	return Feed{
+		ID:       "https://chirpy.example/api/users/1/feed.atom",
+		Title:    "Chirps by 1",
+		Link:     "https://chirpy.example/api/chirps?author_id=1",
+		SelfLink: "https://chirpy.example/api/users/1/feed.atom",
+		Updated:  published,
+		Entries: []Entry{{
+			ID:         "https://chirpy.example/api/chirps/42",
+			Link:       "https://chirpy.example/api/chirps/42",
+			AuthorName: "1",
+			Content:    "<script>alert(1)</script> & \"friends\"\x00\x07",
+			Published:  published,
+			Updated:    published,
+		}},
+	}
+}
+
+func TestAtomIsWellFormedAndEscaped(t *testing.T) {
+	data, err := testFeed().Atom()
+	if err != nil {
+		t.Fatalf("Atom returned error: %v", err)
+	}
+	if strings.Contains(string(data), "<script>") {
+		t.Fatalf("content was not escaped:\n%s", data)
+	}
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("feed is not well-formed: %v\n%s", err, data)
+	}
+	if doc.Updated != "2026-10-19T09:30:00Z" {
+		t.Errorf("updated = %q", doc.Updated)
+	}
+	if len(doc.Entries) != 1 || doc.Entries[0].ID != "https://chirpy.example/api/chirps/42" {
+		t.Fatalf("entries = %+v", doc.Entries)
+	}
+	// control characters aren't allowed in XML and are replaced
+	if want := "<script>alert(1)</script> & \"friends\"��"; doc.Entries[0].Content != want {
+		t.Errorf("content round-tripped as %q, want %q", doc.Entries[0].Content, want)
+	}
+}
+
+func TestRSSIsWellFormedAndEscaped(t *testing.T) {
+	data, err := testFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS returned error: %v", err)
+	}
+
+	var doc struct {
+		Channel struct {
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Description string `xml:"description"`
+				GUID        struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("feed is not well-formed: %v\n%s", err, data)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	// descriptions are HTML, so markup in a chirp must stay text
+	if strings.Contains(item.Description, "<script>") {
+		t.Errorf("description is not HTML-escaped: %q", item.Description)
+	}
+	if item.GUID.Value != "https://chirpy.example/api/chirps/42" || item.GUID.IsPermaLink != "true" {
+		t.Errorf("guid = %+v", item.GUID)
+	}
+	if item.PubDate != "Mon, 19 Oct 2026 09:30:00 +0000" {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"short chirp", "short chirp"},
+		{"line one\n\nline   two", "line one line two"},
+		{strings.Repeat("a", 60), strings.Repeat("a", 60)},
+		{strings.Repeat("é", 61), strings.Repeat("é", 59) + "…"},
+	}
+	for _, tt := range tests {
+		if got := Title(tt.body); got != tt.want {
+			t.Errorf("Title(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+	"golang.org/x/net/html"
+)
+
+const (
+	userAgent = "Chirpy-LinkPreview/1.0"
+
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+)
+
+// ErrNoPreview is returned for pages that aren't HTML or have no title.
+var ErrNoPreview = errors.New("page has no preview metadata")
+
+// urlPattern matches http and https URLs up to the next space or character
+// that can't appear unescaped in a URL.
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// Find returns every URL in text in order, duplicates included. Trailing
+// punctuation is left out, so "see https://example.com." finds
+// "https://example.com", as is a closing parenthesis with no opening one in
+// the URL.
+func Find(text string) []string {
+	urls := []string{}
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		match = strings.TrimRight(match, ".,:;!?'")
+		if strings.HasSuffix(match, ")") && !strings.Contains(match, "(") {
+			match = strings.TrimRight(match, ")")
+		}
+		if u, err := url.Parse(match); err != nil || u.Host == "" {
+			continue
+		}
+		urls = append(urls, match)
+	}
+	return urls
+}
+
+// Preview is the OpenGraph metadata of a page, falling back to its <title>
+// and description meta tag.
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher fetches previews from untrusted URLs. It only connects to public
+// addresses, see netguard.NewClient, and reads at most maxBytes of a page.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+	// allow reports whether an address may be connected to; tests loosen it
+	// to reach httptest servers
+	allow func(netip.Addr) bool
+}
+
+func NewFetcher(timeout time.Duration, maxBytes int64) *Fetcher {
+	f := &Fetcher{maxBytes: maxBytes, allow: netguard.IsPublic}
+	f.client = netguard.NewClient(timeout, func(addr netip.Addr) bool {
+		return f.allow(addr)
+	})
+	return f
+}
+
+func checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("url has no host")
+	}
+	return nil
+}
+
+// Fetch GETs rawURL and reads its preview metadata from the page's <head>.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if err := checkURL(u); err != nil {
+		return Preview{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("page responded with status %d", resp.StatusCode)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return Preview{}, fmt.Errorf("%w: content type %q", ErrNoPreview, resp.Header.Get("Content-Type"))
+	}
+
+	preview, err := parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if preview.Title == "" {
+		return Preview{}, ErrNoPreview
+	}
+	return preview, nil
+}
+
+// parse reads metadata from an HTML document up to the end of its <head>.
+// Relative image URLs are resolved against base.
+func parse(r io.Reader, base *url.URL) (Preview, error) {
+	var og, fallback Preview
+	var inTitle bool
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// a page cut off at the size cap still yields what was read
+			if z.Err() != io.EOF {
+				return Preview{}, z.Err()
+			}
+			return merge(og, fallback, base), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return merge(og, fallback, base), nil
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				var property, content string
+				for {
+					key, val, more := z.TagAttr()
+					switch string(key) {
+					case "property", "name":
+						property = strings.ToLower(string(val))
+					case "content":
+						content = string(val)
+					}
+					if !more {
+						break
+					}
+				}
+				switch property {
+				case "og:title":
+					og.Title = content
+				case "og:description":
+					og.Description = content
+				case "og:image", "og:image:url":
+					if og.ImageURL == "" {
+						og.ImageURL = content
+					}
+				case "og:site_name":
+					og.SiteName = content
+				case "description":
+					fallback.Description = content
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "head":
+				return merge(og, fallback, base), nil
+			case "title":
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle {
+				fallback.Title += string(z.Text())
+			}
+		}
+	}
+}
+
+func merge(og, fallback Preview, base *url.URL) Preview {
+	preview := Preview{
+		Title:       clean(og.Title, maxTitleLength),
+		Description: clean(og.Description, maxDescriptionLength),
+		SiteName:    clean(og.SiteName, maxTitleLength),
+	}
+	if preview.Title == "" {
+		preview.Title = clean(fallback.Title, maxTitleLength)
+	}
+	if preview.Description == "" {
+		preview.Description = clean(fallback.Description, maxDescriptionLength)
+	}
+	if og.ImageURL != "" {
+		if image, err := base.Parse(strings.TrimSpace(og.ImageURL)); err == nil && checkURL(image) == nil {
+			preview.ImageURL = image.String()
+		}
+	}
+	return preview
+}
+
+// clean collapses whitespace, drops invalid UTF-8 and shortens s to at most
+// max characters.
+func clean(s string, max int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
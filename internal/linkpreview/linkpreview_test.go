@@ -0,0 +1,158 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+)
+
+// newTestFetcher returns a fetcher that may also reach loopback addresses,
+// where httptest servers listen, but is otherwise as strict as NewFetcher.
+func newTestFetcher(maxBytes int64) *Fetcher {
+	f := NewFetcher(2*time.Second, maxBytes)
+	f.allow = func(addr netip.Addr) bool {
+		return addr.IsLoopback() || netguard.IsPublic(addr)
+	}
+	return f
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no links here", []string{}},
+		{"see https://example.com.", []string{"https://example.com"}},
+		{"(https://example.com/a) and http://example.org/b?c=d#e!", []string{"https://example.com/a", "http://example.org/b?c=d#e"}},
+		{"wiki https://en.wikipedia.org/wiki/Go_(programming_language)", []string{"https://en.wikipedia.org/wiki/Go_(programming_language)"}},
+		{"twice https://example.com https://example.com", []string{"https://example.com", "https://example.com"}},
+		{"HTTPS://EXAMPLE.COM", []string{"HTTPS://EXAMPLE.COM"}},
+		{"not a link: ftp://example.com or https:// alone", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Find(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFetchReadsOpenGraph(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!doctype html><html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="  The &amp; Title ">
+<meta property="og:description" content="A description">
+<meta property="og:image" content="/images/card.png">
+<meta property="og:site_name" content="Example">
+</head><body><meta property="og:title" content="ignored"></body></html>`))
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL+"/post")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	want := Preview{
+		Title:       "The & Title",
+		Description: "A description",
+		ImageURL:    server.URL + "/images/card.png",
+		SiteName:    "Example",
+	}
+	if preview != want {
+		t.Errorf("Fetch = %+v, want %+v", preview, want)
+	}
+}
+
+func TestFetchFallsBackToTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Plain   page</title><meta name="description" content="About it"></head></html>`))
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if preview.Title != "Plain page" || preview.Description != "About it" || preview.ImageURL != "" {
+		t.Errorf("Fetch = %+v", preview)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked server was contacted")
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher(2*time.Second, 1<<20).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, netguard.ErrBlockedAddress) {
+		t.Fatalf("Fetch returned %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestFetchBlocksRedirectToPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, netguard.ErrBlockedAddress) {
+		t.Fatalf("Fetch returned %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("<title>not really</title>"))
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrNoPreview) {
+		t.Fatalf("Fetch returned %v, want ErrNoPreview", err)
+	}
+}
+
+func TestFetchStopsAtSizeCap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!-- " + strings.Repeat("x", 64<<10) + ` --><title>Too far</title></head></html>`))
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(16<<10).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrNoPreview) {
+		t.Fatalf("Fetch returned %v, want ErrNoPreview", err)
+	}
+}
+
+func TestFetchTimesOut(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	f := NewFetcher(100*time.Millisecond, 1<<20)
+	f.allow = func(addr netip.Addr) bool { return addr.IsLoopback() }
+	start := time.Now()
+	if _, err := f.Fetch(context.Background(), server.URL); err == nil {
+		t.Fatal("Fetch returned no error for a server that never responds")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fetch took %s, want about 100ms", elapsed)
+	}
+}
@@ -0,0 +1,140 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// twoToneImage is red on its left half and blue on its right half.
+func twoToneImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// withEXIFOrientation inserts a big-endian EXIF APP1 segment holding only an
+// Orientation tag right after the JPEG SOI marker.
+func withEXIFOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)           // one entry
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)      // Orientation
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)           // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)           // count
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation) // value
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)                   // padding, next IFD
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	if got, err := Sniff(encodePNG(t, twoToneImage(2, 2))); err != nil || got != ContentTypePNG {
+		t.Errorf("Sniff(png) = %q, %v", got, err)
+	}
+	if got, err := Sniff(encodeJPEG(t, twoToneImage(2, 2))); err != nil || got != ContentTypeJPEG {
+		t.Errorf("Sniff(jpeg) = %q, %v", got, err)
+	}
+	for _, data := range [][]byte{[]byte("GIF89a..."), []byte("<svg></svg>"), []byte("hello")} {
+		if _, err := Sniff(data); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("Sniff(%q) returned %v, want ErrUnsupportedType", data, err)
+		}
+	}
+}
+
+func TestProcessStripsEXIFAndAppliesOrientation(t *testing.T) {
+	data := withEXIFOrientation(t, encodeJPEG(t, twoToneImage(40, 20)), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("test image orientation = %d, want 6", jpegOrientation(data))
+	}
+
+	original, thumbnail, err := Process(data, 10)
+	if err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+	if bytes.Contains(original.Data, []byte("Exif")) || bytes.Contains(thumbnail.Data, []byte("Exif")) {
+		t.Error("processed image still contains EXIF data")
+	}
+	if original.ContentType != ContentTypeJPEG {
+		t.Errorf("ContentType = %q, want %q", original.ContentType, ContentTypeJPEG)
+	}
+	if original.Width != 20 || original.Height != 40 {
+		t.Errorf("original is %dx%d, want 20x40 after rotation", original.Width, original.Height)
+	}
+	if thumbnail.Width != 5 || thumbnail.Height != 10 {
+		t.Errorf("thumbnail is %dx%d, want 5x10", thumbnail.Width, thumbnail.Height)
+	}
+
+	// rotating clockwise puts the red left half on top
+	img, err := jpeg.Decode(bytes.NewReader(original.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := color.RGBAModel.Convert(img.At(10, 5)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(img.At(10, 35)).(color.RGBA)
+	if top.R < 200 || top.B > 60 {
+		t.Errorf("top pixel = %v, want red", top)
+	}
+	if bottom.B < 200 || bottom.R > 60 {
+		t.Errorf("bottom pixel = %v, want blue", bottom)
+	}
+}
+
+func TestDecodeRejectsLargeDimensions(t *testing.T) {
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1)))
+	if _, _, err := Decode(data); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Decode returned %v, want ErrTooLarge", err)
+	}
+}
+
+func TestFitAndSquare(t *testing.T) {
+	img := twoToneImage(300, 100)
+
+	if got := Fit(img, 150, 150).Bounds(); got.Dx() != 150 || got.Dy() != 50 {
+		t.Errorf("Fit = %dx%d, want 150x50", got.Dx(), got.Dy())
+	}
+	if got := Fit(img, 400, 400); got != image.Image(img) {
+		t.Error("Fit scaled an image that already fits")
+	}
+	if got := Square(img, 64).Bounds(); got.Dx() != 64 || got.Dy() != 64 {
+		t.Errorf("Square = %dx%d, want 64x64", got.Dx(), got.Dy())
+	}
+}
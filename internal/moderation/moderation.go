@@ -0,0 +1,203 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+type Action string
+
+const (
+	// ActionMask replaces the matched word with asterisks.
+	ActionMask Action = "mask"
+	// ActionReject refuses the whole chirp.
+	ActionReject Action = "reject"
+	// ActionFlag accepts the chirp unchanged and queues it for review.
+	ActionFlag Action = "flag"
+
+	maskedWord = "****"
+)
+
+func (a Action) Valid() bool {
+	switch a {
+	case ActionMask, ActionReject, ActionFlag:
+		return true
+	}
+	return false
+}
+
+type Rule struct {
+	Term   string
+	Action Action
+}
+
+type Match struct {
+	Term   string `json:"term"`
+	Action Action `json:"action"`
+}
+
+// Result is the outcome of moderating a chirp body. Body holds the body after
+// masking; it is only meaningful when Rejected is false.
+type Result struct {
+	Body     string
+	Rejected bool
+	Flagged  bool
+	Matches  []Match
+}
+
+// Stage is one step of a moderation pipeline.
+type Stage interface {
+	Moderate(body string) Result
+}
+
+type Pipeline struct {
+	stages []Stage
+}
+
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Moderate runs every stage in order, feeding each the body produced by the
+// previous one.
+func (p *Pipeline) Moderate(body string) Result {
+	res := Result{Body: body}
+	for _, stage := range p.stages {
+		stageRes := stage.Moderate(res.Body)
+		res.Body = stageRes.Body
+		res.Rejected = res.Rejected || stageRes.Rejected
+		res.Flagged = res.Flagged || stageRes.Flagged
+		res.Matches = append(res.Matches, stageRes.Matches...)
+	}
+	return res
+}
+
+// WordList matches normalized words against a set of rules. Its rules can be
+// swapped at any time without blocking concurrent Moderate calls.
+type WordList struct {
+	rules atomic.Pointer[map[string]Action]
+}
+
+func NewWordList(rules []Rule) *WordList {
+	w := &WordList{}
+	w.Replace(rules)
+	return w
+}
+
+// Replace swaps in a new set of rules.
+func (w *WordList) Replace(rules []Rule) {
+	m := make(map[string]Action, len(rules))
+	for _, rule := range rules {
+		m[Normalize(rule.Term)] = rule.Action
+	}
+	w.rules.Store(&m)
+}
+
+func (w *WordList) Moderate(body string) Result {
+	rules := *w.rules.Load()
+	res := Result{}
+
+	var b strings.Builder
+	for _, tok := range tokenize(body) {
+		if !tok.word {
+			b.WriteString(tok.text)
+			continue
+		}
+		term := Normalize(tok.text)
+		action, ok := rules[term]
+		if !ok {
+			b.WriteString(tok.text)
+			continue
+		}
+
+		res.Matches = append(res.Matches, Match{Term: term, Action: action})
+		switch action {
+		case ActionMask:
+			b.WriteString(maskedWord)
+		case ActionReject:
+			res.Rejected = true
+			b.WriteString(tok.text)
+		case ActionFlag:
+			res.Flagged = true
+			b.WriteString(tok.text)
+		default:
+			b.WriteString(tok.text)
+		}
+	}
+
+	res.Body = b.String()
+	return res
+}
+
+// leetReplacements maps common character substitutions back to letters.
+var leetReplacements = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// Normalize lowercases a word and undoes leetspeak substitutions, so
+// "K3rfuffl3" and "kerfuffle" compare equal.
+func Normalize(word string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if replacement, ok := leetReplacements[r]; ok {
+			return replacement
+		}
+		return r
+	}, word)
+}
+
+// NormalizeTerm normalizes a rule term and checks it is a single word.
+func NormalizeTerm(term string) (string, error) {
+	words := 0
+	for _, tok := range tokenize(term) {
+		if tok.word {
+			words++
+		}
+	}
+	if words != 1 {
+		return "", fmt.Errorf("term must be a single word")
+	}
+	return Normalize(strings.TrimFunc(term, func(r rune) bool { return !isWordRune(r) })), nil
+}
+
+type token struct {
+	text string
+	word bool
+}
+
+// tokenize splits s into alternating runs of word and non-word characters.
+// Joining the token texts gives back s.
+func tokenize(s string) []token {
+	var tokens []token
+	start := 0
+	inWord := false
+	for i, r := range s {
+		w := isWordRune(r)
+		if i > 0 && w != inWord {
+			tokens = append(tokens, token{text: s[start:i], word: inWord})
+			start = i
+		}
+		inWord = w
+	}
+	if start < len(s) {
+		tokens = append(tokens, token{text: s[start:], word: inWord})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) {
+		return true
+	}
+	_, ok := leetReplacements[r]
+	return ok
+}
@@ -0,0 +1,116 @@
+package moderation
+
+import (
+	"testing"
+)
+
+func defaultRules() []Rule {
+	return []Rule{
+		{Term: "kerfuffle", Action: ActionMask},
+		{Term: "sharbert", Action: ActionMask},
+		{Term: "fornax", Action: ActionMask},
+	}
+}
+
+func TestWordListMasks(t *testing.T) {
+	w := NewWordList(defaultRules())
+
+	cases := []struct {
+		body string
+		want string
+	}{
+		{"I had something interesting for breakfast", "I had something interesting for breakfast"},
+		{"I hear Mastodon is better than Chirpy. sharbert I need to migrate", "I hear Mastodon is better than Chirpy. **** I need to migrate"},
+		{"I really need a kerfuffle to go to bed sooner, Fornax !", "I really need a **** to go to bed sooner, **** !"},
+		{"Kerfuffle!", "****!"},
+		{"what a\tkerfuffle\nindeed", "what a\t****\nindeed"},
+		{"sharbert,fornax", "****,****"},
+		{"K3rfuffl3 and f0rn@x", "**** and ****"},
+		{"KERFUFFLE", "****"},
+		{"kerfuffles", "kerfuffles"},
+	}
+	for _, c := range cases {
+		res := w.Moderate(c.body)
+		if res.Body != c.want {
+			t.Errorf("Moderate(%q) = %q, want %q", c.body, res.Body, c.want)
+		}
+		if res.Rejected || res.Flagged {
+			t.Errorf("Moderate(%q) rejected=%v flagged=%v, want neither", c.body, res.Rejected, res.Flagged)
+		}
+	}
+}
+
+func TestWordListActions(t *testing.T) {
+	w := NewWordList([]Rule{
+		{Term: "spam", Action: ActionReject},
+		{Term: "sus", Action: ActionFlag},
+	})
+
+	res := w.Moderate("buy my SP4M now")
+	if !res.Rejected {
+		t.Errorf("expected chirp to be rejected")
+	}
+
+	res = w.Moderate("that's sus.")
+	if res.Rejected || !res.Flagged {
+		t.Errorf("expected chirp to be flagged only, got rejected=%v flagged=%v", res.Rejected, res.Flagged)
+	}
+	if res.Body != "that's sus." {
+		t.Errorf("flagged chirp body changed to %q", res.Body)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Term != "sus" {
+		t.Errorf("unexpected matches: %+v", res.Matches)
+	}
+}
+
+func TestWordListReplace(t *testing.T) {
+	w := NewWordList(defaultRules())
+	w.Replace([]Rule{{Term: "grok", Action: ActionMask}})
+
+	if got := w.Moderate("kerfuffle grok").Body; got != "kerfuffle ****" {
+		t.Errorf("Moderate after Replace = %q, want %q", got, "kerfuffle ****")
+	}
+}
+
+func TestWordListUnicode(t *testing.T) {
+	w := NewWordList([]Rule{{Term: "café", Action: ActionMask}})
+
+	if got := w.Moderate("Un CAFÉ, s'il vous plaît").Body; got != "Un ****, s'il vous plaît" {
+		t.Errorf("Moderate = %q", got)
+	}
+}
+
+func TestPipelineCombinesStages(t *testing.T) {
+	p := NewPipeline(
+		NewWordList(defaultRules()),
+		NewWordList([]Rule{{Term: "sus", Action: ActionFlag}}),
+	)
+
+	res := p.Moderate("sus kerfuffle")
+	if res.Body != "sus ****" {
+		t.Errorf("Pipeline body = %q, want %q", res.Body, "sus ****")
+	}
+	if !res.Flagged {
+		t.Errorf("expected pipeline result to be flagged")
+	}
+	if len(res.Matches) != 2 {
+		t.Errorf("expected 2 matches, got %+v", res.Matches)
+	}
+}
+
+func TestNormalizeTerm(t *testing.T) {
+	got, err := NormalizeTerm("  Sh4rbert! ")
+	if err != nil {
+		t.Fatalf("NormalizeTerm returned error: %v", err)
+	}
+	if got != "sharbert" {
+		t.Errorf("NormalizeTerm = %q, want %q", got, "sharbert")
+	}
+
+	if _, err := NormalizeTerm("two words"); err == nil {
+		t.Error("NormalizeTerm did not return error for two words")
+	}
+	if _, err := NormalizeTerm("!!!"); err == nil {
+		t.Error("NormalizeTerm did not return error for punctuation only")
+	}
+}
@@ -0,0 +1,100 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const maxRedirects = 5
+
+// ErrBlockedAddress is returned for URLs that resolve to loopback, private,
+// link-local or otherwise non-public addresses.
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// NewClient returns an HTTP client that only connects to addresses allow
+// accepts. The check runs when dialing, after DNS resolution, so redirects
+// and DNS rebinding can't get around it. Environment proxies are ignored,
+// and at most 5 redirects to http or https URLs are followed.
+func NewClient(timeout time.Duration, allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addrPort.Addr().Unmap()) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// an environment proxy would make the dial check meaningless
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: CheckRedirect,
+	}
+}
+
+// CheckRedirect is the redirect policy of clients from NewClient, for
+// callers that add their own checks to it.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("too many redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+	}
+	if req.URL.Host == "" {
+		return errors.New("redirect url has no host")
+	}
+	return nil
+}
+
+// IsPublic reports whether addr is a publicly routable unicast address.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowAll accepts every address. It is for local development, where the
+// remote ends are usually on the same machine or network.
+func AllowAll(netip.Addr) bool {
+	return true
+}
+
+// nonPublicPrefixes are reserved ranges IsGlobalUnicast and IsPrivate don't
+// cover.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, can embed private IPv4
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
@@ -0,0 +1,84 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"::ffff:10.0.0.1":      false,
+		"224.0.0.1":            false,
+	} {
+		if got := IsPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("IsPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func get(client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestClientBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked server was contacted")
+	}))
+	defer server.Close()
+
+	err := get(NewClient(2*time.Second, IsPublic), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("GET returned %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestClientBlocksRedirectToPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(2*time.Second, func(addr netip.Addr) bool {
+		return addr.IsLoopback() || IsPublic(addr)
+	})
+	if err := get(client, server.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("GET returned %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestClientStopsRedirectLoops(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+
+	if err := get(NewClient(2*time.Second, AllowAll), server.URL+"/"); err == nil {
+		t.Fatal("GET followed redirects forever")
+	}
+}
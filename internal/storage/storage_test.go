@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testBlobStore runs the behaviour every BlobStore implementation shares.
+func testBlobStore(t *testing.T, store BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "media/a b.png", "image/png", []byte("first")); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	if err := store.Put(ctx, "media/a b.png", "image/png", []byte("second")); err != nil {
+		t.Fatalf("Put overwrite returned error: %v", err)
+	}
+
+	rc, err := store.Get(ctx, "media/a b.png")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("reading blob: %v", err)
+	}
+	if string(got) != "second" {
+		t.Errorf("Get returned %q, want %q", got, "second")
+	}
+
+	if err := store.Delete(ctx, "media/a b.png"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := store.Get(ctx, "media/a b.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete returned %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "media/a b.png"); err != nil {
+		t.Errorf("Delete of missing blob returned error: %v", err)
+	}
+
+	if err := store.Put(ctx, "../escape", "text/plain", []byte("x")); err == nil {
+		t.Error("Put accepted a key escaping the store")
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore returned error: %v", err)
+	}
+	testBlobStore(t, store)
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"media/abc.jpg", true},
+		{"avatars/abc/128.png", true},
+		{"", false},
+		{"/media/abc.jpg", false},
+		{"media//abc.jpg", false},
+		{"media/../abc.jpg", false},
+		{"media/./abc.jpg", false},
+		{"media\\abc.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event is one message fanned out to subscribers. IDs increase over time so
+// clients can resume after the last one they saw.
+type Event struct {
+	ID     int64
+	Type   string
+	UserID uuid.UUID
+	Data   []byte
+}
+
+// Subscription receives events published to a Hub. Its channel is closed
+// when it is unsubscribed or dropped for falling behind.
+type Subscription struct {
+	events  chan Event
+	dropped bool
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped reports whether the subscription was closed because its buffer
+// filled up. Only meaningful once Events is closed.
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+// Hub fans events out to subscribers. Publishing never blocks: a subscriber
+// whose buffer is full is dropped so one slow client can't hold up the rest.
+type Hub struct {
+	mu         sync.Mutex
+	subs       map[*Subscription]struct{}
+	bufferSize int
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		subs:       map[*Subscription]struct{}{},
+		bufferSize: bufferSize,
+	}
+}
+
+func (h *Hub) Subscribe() *Subscription {
+	sub := &Subscription{events: make(chan Event, h.bufferSize)}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+}
+
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped = true
+			delete(h.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Len returns the number of live subscriptions.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// WriteEvent writes event in Server-Sent Events format.
+func WriteEvent(w io.Writer, event Event) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\n", event.ID)
+	if event.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", event.Type)
+	}
+	for _, line := range strings.Split(string(event.Data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteComment writes an SSE comment line, which clients ignore; it is used
+// as a heartbeat to keep idle connections open.
+func WriteComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubFansOut(t *testing.T) {
+	hub := NewHub(4)
+	a := hub.Subscribe()
+	b := hub.Subscribe()
+
+	hub.Publish(Event{ID: 1, Type: "chirp.created"})
+
+	for _, sub := range []*Subscription{a, b} {
+		select {
+		case event := <-sub.Events():
+			if event.ID != 1 {
+				t.Errorf("got event %d, want 1", event.ID)
+			}
+		default:
+			t.Fatal("subscriber did not receive event")
+		}
+	}
+
+	hub.Unsubscribe(a)
+	if _, ok := <-a.Events(); ok {
+		t.Error("unsubscribed channel is still open")
+	}
+	if a.Dropped() {
+		t.Error("unsubscribed subscription reported as dropped")
+	}
+	if hub.Len() != 1 {
+		t.Errorf("hub has %d subscribers, want 1", hub.Len())
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(2)
+	slow := hub.Subscribe()
+	fast := hub.Subscribe()
+
+	for i := int64(1); i <= 3; i++ {
+		hub.Publish(Event{ID: i})
+		if i < 3 {
+			<-fast.Events()
+		}
+	}
+
+	// the slow subscriber gets what fit in its buffer, then a closed channel
+	got := []int64{}
+	for event := range slow.Events() {
+		got = append(got, event.ID)
+	}
+	if len(got) != 2 || !slow.Dropped() {
+		t.Errorf("slow subscriber got %v, dropped=%v; want [1 2], dropped=true", got, slow.Dropped())
+	}
+	if event := <-fast.Events(); event.ID != 3 {
+		t.Errorf("fast subscriber got event %d, want 3", event.ID)
+	}
+	if hub.Len() != 1 {
+		t.Errorf("hub has %d subscribers, want 1", hub.Len())
+	}
+
+	// unsubscribing a dropped subscription is harmless
+	hub.Unsubscribe(slow)
+}
+
+func TestWriteEvent(t *testing.T) {
+	var b strings.Builder
+	err := WriteEvent(&b, Event{
+		ID:     42,
+		Type:   "chirp.created",
+		UserID: uuid.New(),
+		Data:   []byte("{\"body\":\"hi\"}\nsecond line"),
+	})
+	if err != nil {
+		t.Fatalf("WriteEvent returned error: %v", err)
+	}
+	want := "id: 42\nevent: chirp.created\ndata: {\"body\":\"hi\"}\ndata: second line\n\n"
+	if b.String() != want {
+		t.Errorf("WriteEvent wrote %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	WriteComment(&b, "ping")
+	if b.String() != ": ping\n\n" {
+		t.Errorf("WriteComment wrote %q", b.String())
+	}
+}
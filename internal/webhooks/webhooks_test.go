@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+)
+
+// newTestClient returns a client that may reach loopback addresses, where
+// httptest servers listen.
+func newTestClient() *Client {
+	return NewClient(time.Second, func(addr netip.Addr) bool {
+		return addr.IsLoopback() || netguard.IsPublic(addr)
+	})
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret returned error: %v", err)
+	}
+	body := []byte(`{"type":"chirp.created"}`)
+
+	var verifyErr error
+	var gotEvent string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		verifyErr = Verify(secret, r.Header.Get(SignatureHeader), got, time.Minute, time.Now())
+		gotEvent = r.Header.Get(EventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, err := newTestClient().Deliver(context.Background(), receiver.URL, secret, "delivery-1", "chirp.created", body)
+	if err != nil {
+		t.Fatalf("Deliver returned error: %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("Deliver returned status %d, want %d", status, http.StatusNoContent)
+	}
+	if verifyErr != nil {
+		t.Errorf("receiver could not verify signature: %v", verifyErr)
+	}
+	if gotEvent != "chirp.created" {
+		t.Errorf("receiver got event %q, want %q", gotEvent, "chirp.created")
+	}
+}
+
+func TestDeliverNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := newTestClient().Deliver(context.Background(), receiver.URL, "secret", "delivery-1", "chirp.created", []byte(`{}`))
+	if err == nil {
+		t.Fatal("Deliver did not return error for 503 response")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Deliver returned status %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestDeliverBlocksPrivateAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked receiver was contacted")
+	}))
+	defer receiver.Close()
+
+	status, err := NewClient(time.Second, netguard.IsPublic).Deliver(context.Background(), receiver.URL, "secret", "delivery-1", "chirp.created", []byte(`{}`))
+	if !errors.Is(err, netguard.ErrBlockedAddress) {
+		t.Fatalf("Deliver returned %v, want ErrBlockedAddress", err)
+	}
+	if got := DescribeError(status, err); got != "webhook endpoint address is not publicly routable" {
+		t.Errorf("DescribeError = %q", got)
+	}
+}
+
+func TestDescribeErrorHidesTransportDetail(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{http.StatusBadGateway, errors.New("webhook endpoint responded with status 502"), "webhook endpoint responded with status 502"},
+		{0, fmt.Errorf("Post: %w", context.DeadlineExceeded), "webhook endpoint timed out"},
+		{0, errors.New("dial tcp 10.0.0.5:6379: connect: connection refused"), "couldn't connect to webhook endpoint"},
+	}
+	for _, tt := range tests {
+		if got := DescribeError(tt.status, tt.err); got != tt.want {
+			t.Errorf("DescribeError(%d, %v) = %q, want %q", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedBody(t *testing.T) {
+	now := time.Now()
+	header := Sign("secret", now, []byte(`{"id":1}`))
+
+	if err := Verify("secret", header, []byte(`{"id":2}`), time.Minute, now); err == nil {
+		t.Fatal("Verify did not return error for tampered body")
+	}
+	if err := Verify("other-secret", header, []byte(`{"id":1}`), time.Minute, now); err == nil {
+		t.Fatal("Verify did not return error for wrong secret")
+	}
+}
+
+func TestVerifyRejectsStaleSignature(t *testing.T) {
+	signedAt := time.Now().Add(-time.Hour)
+	header := Sign("secret", signedAt, []byte(`{}`))
+
+	if err := Verify("secret", header, []byte(`{}`), 5*time.Minute, time.Now()); err == nil {
+		t.Fatal("Verify did not return error for stale signature")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, c := range cases {
+		if got := Backoff(c.attempts); got != c.want {
+			t.Errorf("Backoff(%d) = %s, want %s", c.attempts, got, c.want)
+		}
+	}
+}
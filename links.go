@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/linkpreview"
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+	"github.com/bontaramsonta/go-chirpy/internal/webhooks"
+)
+
+const (
+	// linkLength is what each URL counts as towards the chirp length limit,
+	// however long it is
+	linkLength = 23
+
+	LinkPreviewPending = "pending"
+	LinkPreviewFetched = "fetched"
+	LinkPreviewFailed  = "failed"
+
+	linkPreviewInterval  = 5 * time.Second
+	linkPreviewTimeout   = 5 * time.Second
+	linkPreviewMaxBytes  = 512 << 10
+	linkPreviewBatchSize = 10
+	// a claimed preview is retried after the lease if the worker dies mid-fetch
+	linkPreviewLease       = 2 * time.Minute
+	linkPreviewMaxAttempts = 3
+)
+
+type Link struct {
+	URL     string       `json:"url"`
+	Preview *LinkPreview `json:"preview,omitempty"`
+}
+
+// LinkPreview is a link's preview card. It is left out until the page has
+// been fetched, and for pages without preview metadata.
+type LinkPreview struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+func linkFromDB(dbPreview database.LinkPreview) Link {
+	link := Link{URL: dbPreview.Url}
+	if dbPreview.Status == LinkPreviewFetched {
+		link.Preview = &LinkPreview{
+			Title:       dbPreview.Title.String,
+			Description: dbPreview.Description.String,
+			ImageURL:    dbPreview.ImageUrl.String,
+			SiteName:    dbPreview.SiteName.String,
+		}
+	}
+	return link
+}
+
+// storeChirpLinks replaces the links stored for a chirp with the URLs in
+// body, queueing a preview fetch for URLs not seen before.
+func storeChirpLinks(ctx context.Context, q *database.Queries, chirpID int32, body string) error {
+	if err := q.DeleteChirpLinks(ctx, chirpID); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, url := range linkpreview.Find(body) {
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		if err := q.AddChirpLink(ctx, database.AddChirpLinkParams{
+			ChirpID:  chirpID,
+			Position: int32(len(seen) - 1),
+			Url:      url,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runLinkPreviews periodically fetches previews for newly linked URLs.
+// Previews are claimed with row locks, so several instances can run this
+// safely.
+func (cfg *apiConfig) runLinkPreviews(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		previews, err := cfg.db.ClaimDueLinkPreviews(ctx, database.ClaimDueLinkPreviewsParams{
+			LeaseSeconds: int32(linkPreviewLease.Seconds()),
+			BatchSize:    linkPreviewBatchSize,
+		})
+		if err != nil {
+			log.Printf("Error claiming link previews: %s", err)
+		}
+		for _, preview := range previews {
+			cfg.fetchLinkPreview(ctx, preview)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) fetchLinkPreview(ctx context.Context, dbPreview database.LinkPreview) {
+	preview, fetchErr := cfg.linkFetcher.Fetch(ctx, dbPreview.Url)
+	if fetchErr == nil {
+		if err := cfg.db.MarkLinkPreviewFetched(ctx, database.MarkLinkPreviewFetchedParams{
+			Url:         dbPreview.Url,
+			Title:       sql.NullString{String: preview.Title, Valid: true},
+			Description: sql.NullString{String: preview.Description, Valid: preview.Description != ""},
+			ImageUrl:    sql.NullString{String: preview.ImageURL, Valid: preview.ImageURL != ""},
+			SiteName:    sql.NullString{String: preview.SiteName, Valid: preview.SiteName != ""},
+		}); err != nil {
+			log.Printf("Error recording link preview for %s: %s", dbPreview.Url, err)
+		}
+		return
+	}
+
+	status := LinkPreviewPending
+	// internal addresses and pages without metadata won't change on retry
+	permanent := errors.Is(fetchErr, netguard.ErrBlockedAddress) || errors.Is(fetchErr, linkpreview.ErrNoPreview)
+	if dbPreview.Attempts >= linkPreviewMaxAttempts || permanent {
+		status = LinkPreviewFailed
+	}
+	if err := cfg.db.MarkLinkPreviewFailed(ctx, database.MarkLinkPreviewFailedParams{
+		Url:               dbPreview.Url,
+		Status:            status,
+		LastError:         sql.NullString{String: fetchErr.Error(), Valid: true},
+		RetryAfterSeconds: int32(webhooks.Backoff(int(dbPreview.Attempts)).Seconds()),
+	}); err != nil {
+		log.Printf("Error recording link preview for %s: %s", dbPreview.Url, err)
+	}
+}
@@ -1,23 +1,50 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/bontaramsonta/go-chirpy/internal/activitypub"
 	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/entitlements"
+	"github.com/bontaramsonta/go-chirpy/internal/linkpreview"
+	"github.com/bontaramsonta/go-chirpy/internal/moderation"
+	"github.com/bontaramsonta/go-chirpy/internal/netguard"
+	"github.com/bontaramsonta/go-chirpy/internal/storage"
+	"github.com/bontaramsonta/go-chirpy/internal/stream"
+	"github.com/bontaramsonta/go-chirpy/internal/webhooks"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	jwtSecret      string
-	polkaKey       string
+	fileserverHits     atomic.Int32
+	db                 *database.Queries
+	dbConn             *sql.DB
+	platform           string
+	jwtSecret          string
+	polkaKey           string
+	adminKey           string
+	plans              entitlements.Plans
+	webhookClient      *webhooks.Client
+	wordList           *moderation.WordList
+	moderator          *moderation.Pipeline
+	userStatuses       *userStatusCache
+	blobs              storage.BlobStore
+	chirpStream        *stream.Hub
+	notificationStream *stream.Hub
+	apClient           *activitypub.Client
+	linkFetcher        *linkpreview.Fetcher
+
+	baseURL            string
+	chirpRestoreWindow time.Duration
 }
 
 func main() {
@@ -28,6 +55,7 @@ func main() {
 	dbURL := os.Getenv("DB_URL")
 	jwtSecret := os.Getenv("JWT_SECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
+	adminKey := os.Getenv("ADMIN_API_KEY")
 
 	if dbURL == "" {
 		log.Fatal("DB_URL must be set")
@@ -37,18 +65,78 @@ func main() {
 		log.Fatal("PLATFORM must be set")
 	}
 
+	// absolute links in feeds are built from BASE_URL
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+	if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("BASE_URL must be an absolute http or https URL: %s", baseURL)
+	}
+
+	chirpRestoreWindow := defaultChirpRestoreWindow
+	if window := os.Getenv("CHIRP_RESTORE_WINDOW"); window != "" {
+		parsed, err := time.ParseDuration(window)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("CHIRP_RESTORE_WINDOW must be a positive duration: %s", window)
+		}
+		chirpRestoreWindow = parsed
+	}
+
+	plans := entitlements.DefaultPlans()
+	if plansPath := os.Getenv("PLANS_CONFIG"); plansPath != "" {
+		loaded, err := entitlements.LoadPlans(plansPath)
+		if err != nil {
+			log.Fatalf("Error loading plans: %s", err)
+		}
+		plans = loaded
+	}
+
+	blobs, err := blobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring media storage: %s", err)
+	}
+
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("Error opening database: %s", err)
 	}
 	dbQueries := database.New(dbConn)
 
+	wordList := moderation.NewWordList(nil)
+
+	// remote servers and webhook endpoints are only reachable on public
+	// addresses, except in development where they usually run locally
+	remoteAllow := netguard.IsPublic
+	if platform == "dev" {
+		remoteAllow = netguard.AllowAll
+	}
+
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       platform,
-		jwtSecret:      jwtSecret,
-		polkaKey:       polkaKey,
+		fileserverHits:     atomic.Int32{},
+		db:                 dbQueries,
+		dbConn:             dbConn,
+		platform:           platform,
+		jwtSecret:          jwtSecret,
+		polkaKey:           polkaKey,
+		adminKey:           adminKey,
+		plans:              plans,
+		webhookClient:      webhooks.NewClient(webhookDeliveryTimeout, remoteAllow),
+		wordList:           wordList,
+		moderator:          moderation.NewPipeline(wordList),
+		userStatuses:       newUserStatusCache(userStatusTTL),
+		blobs:              blobs,
+		chirpStream:        stream.NewHub(chirpStreamBufferSize),
+		notificationStream: stream.NewHub(notificationStreamBufferSize),
+		apClient:           activitypub.NewClient(apDeliveryTimeout, platform == "dev", remoteAllow),
+		linkFetcher:        linkpreview.NewFetcher(linkPreviewTimeout, linkPreviewMaxBytes),
+
+		baseURL:            baseURL,
+		chirpRestoreWindow: chirpRestoreWindow,
+	}
+
+	if err := apiCfg.reloadModerationRules(context.Background()); err != nil {
+		log.Fatalf("Error loading moderation rules: %s", err)
 	}
 
 	mux := http.NewServeMux()
@@ -60,19 +148,107 @@ func main() {
 	mux.HandleFunc("POST /api/users", apiCfg.handlerUsersCreate)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerUsersLogin)
 	mux.Handle("PUT /api/users", apiCfg.middlewareisAuthed(apiCfg.handlerUsersUpdate))
+	mux.Handle("GET /api/users/me/subscription", apiCfg.middlewareisAuthed(apiCfg.handlerUsersSubscription))
+	mux.Handle("GET /api/users/me/bookmarks", apiCfg.middlewareisAuthed(apiCfg.handlerUsersBookmarks))
+	mux.Handle("PUT /api/users/me/avatar", apiCfg.middlewareisAuthed(apiCfg.handlerUsersAvatarUpdate))
+	mux.HandleFunc("GET /api/users/{userID}/avatar", apiCfg.handlerUsersAvatarGet)
+	mux.HandleFunc("GET /api/users/{userID}/feed.atom", apiCfg.handlerUsersFeedAtom)
+	mux.HandleFunc("GET /api/users/{userID}/feed.rss", apiCfg.handlerUsersFeedRSS)
+	mux.Handle("GET /api/users/me/dm_settings", apiCfg.middlewareisAuthed(apiCfg.handlerUsersDMSettingsGet))
+	mux.Handle("PUT /api/users/me/dm_settings", apiCfg.middlewareisAuthed(apiCfg.handlerUsersDMSettingsUpdate))
+	mux.Handle("POST /api/users/{userID}/block", apiCfg.middlewareisAuthed(apiCfg.handlerUsersBlock))
+	mux.Handle("DELETE /api/users/{userID}/block", apiCfg.middlewareisAuthed(apiCfg.handlerUsersUnblock))
+	mux.Handle("POST /api/users/{userID}/mute", apiCfg.middlewareisAuthed(apiCfg.handlerUsersMute))
+	mux.Handle("DELETE /api/users/{userID}/mute", apiCfg.middlewareisAuthed(apiCfg.handlerUsersUnmute))
 	mux.Handle("POST /api/refresh", apiCfg.middlewareCheckRefreshToken(apiCfg.handlerUsersRefresh))
 	mux.Handle("POST /api/revoke", apiCfg.middlewareCheckRefreshToken(apiCfg.handlerUsersRevoke))
 
 	mux.Handle("POST /api/chirps", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsCreate))
+	mux.Handle("PUT /api/chirps/{chirpID}", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsUpdate))
 	mux.Handle("DELETE /api/chirps/{chirpID}", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsDelete))
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsRetrieve)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpRetrieve)
+	mux.Handle("POST /api/chirps/{chirpID}/restore", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsRestore))
+	mux.Handle("POST /api/chirps/{chirpID}/report", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsReport))
+	mux.Handle("POST /api/chirps/{chirpID}/rechirp", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsRechirp))
+	mux.Handle("POST /api/chirps/{chirpID}/bookmark", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsBookmark))
+	mux.Handle("POST /api/chirps/{chirpID}/pin", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsPin))
+	mux.Handle("POST /api/chirps/{chirpID}/vote", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsVote))
+	mux.Handle("GET /api/chirps", apiCfg.middlewareOptionalAuth(apiCfg.handlerChirpsRetrieve))
+	mux.HandleFunc("GET /api/chirps/feed.atom", apiCfg.handlerChirpsFeedAtom)
+	mux.HandleFunc("GET /api/chirps/feed.rss", apiCfg.handlerChirpsFeedRSS)
+	mux.Handle("GET /api/chirps/stream", apiCfg.middlewareOptionalAuth(apiCfg.handlerChirpsStream))
+	mux.Handle("GET /api/ws", apiCfg.middlewareisAuthed(apiCfg.handlerWebSocket))
+	mux.Handle("GET /api/chirps/{chirpID}", apiCfg.middlewareOptionalAuth(apiCfg.handlerChirpRetrieve))
+	mux.Handle("GET /api/chirps/scheduled", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsScheduledRetrieve))
+	mux.Handle("DELETE /api/chirps/scheduled/{chirpID}", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsScheduledDelete))
+	// undoes rechirps, bookmarks and pins; see handlerChirpsActionDelete
+	mux.Handle("DELETE /api/chirps/{chirpID}/{action}", apiCfg.middlewareisAuthed(apiCfg.handlerChirpsActionDelete))
+	mux.Handle("POST /api/media", apiCfg.middlewareisAuthed(apiCfg.handlerMediaUpload))
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handlerMediaGet)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handlerMediaThumbnailGet)
+	mux.Handle("GET /api/notifications", apiCfg.middlewareisAuthed(apiCfg.handlerNotificationsRetrieve))
+	mux.Handle("GET /api/notifications/unread_count", apiCfg.middlewareisAuthed(apiCfg.handlerNotificationsUnreadCount))
+	mux.Handle("POST /api/notifications/read", apiCfg.middlewareisAuthed(apiCfg.handlerNotificationsMarkAllRead))
+	mux.Handle("POST /api/notifications/{notificationID}/read", apiCfg.middlewareisAuthed(apiCfg.handlerNotificationsMarkRead))
+	mux.Handle("POST /api/conversations", apiCfg.middlewareisAuthed(apiCfg.handlerConversationsCreate))
+	mux.Handle("GET /api/conversations", apiCfg.middlewareisAuthed(apiCfg.handlerConversationsRetrieve))
+	mux.Handle("GET /api/conversations/{conversationID}/messages", apiCfg.middlewareisAuthed(apiCfg.handlerConversationMessagesRetrieve))
+	mux.Handle("POST /api/conversations/{conversationID}/messages", apiCfg.middlewareisAuthed(apiCfg.handlerConversationMessagesCreate))
+	mux.Handle("POST /api/conversations/{conversationID}/read", apiCfg.middlewareisAuthed(apiCfg.handlerConversationMarkRead))
+	mux.Handle("POST /api/drafts", apiCfg.middlewareisAuthed(apiCfg.handlerDraftsCreate))
+	mux.Handle("GET /api/drafts", apiCfg.middlewareisAuthed(apiCfg.handlerDraftsRetrieve))
+	mux.Handle("GET /api/drafts/{draftID}", apiCfg.middlewareisAuthed(apiCfg.handlerDraftRetrieve))
+	mux.Handle("PUT /api/drafts/{draftID}", apiCfg.middlewareisAuthed(apiCfg.handlerDraftsUpdate))
+	mux.Handle("DELETE /api/drafts/{draftID}", apiCfg.middlewareisAuthed(apiCfg.handlerDraftsDelete))
+	mux.Handle("POST /api/drafts/{draftID}/publish", apiCfg.middlewareisAuthed(apiCfg.handlerDraftsPublish))
+
+	mux.Handle("GET /api/moderation/reports", apiCfg.middlewareIsModerator(apiCfg.handlerModerationQueue))
+	mux.Handle("POST /api/moderation/reports/{reportID}/dismiss", apiCfg.middlewareIsModerator(apiCfg.handlerModerationReportDismiss))
+	mux.Handle("POST /api/moderation/chirps/{chirpID}/hide", apiCfg.middlewareIsModerator(apiCfg.handlerModerationChirpHide))
+	mux.Handle("POST /api/moderation/chirps/{chirpID}/restore", apiCfg.middlewareIsModerator(apiCfg.handlerModerationChirpRestore))
+	mux.Handle("POST /api/moderation/users/{userID}/suspend", apiCfg.middlewareIsModerator(apiCfg.handlerModerationUserSuspend))
+	mux.Handle("POST /api/moderation/users/{userID}/ban", apiCfg.middlewareIsModerator(apiCfg.handlerModerationUserBan))
+	mux.Handle("POST /api/moderation/users/{userID}/reinstate", apiCfg.middlewareIsModerator(apiCfg.handlerModerationUserReinstate))
+	mux.Handle("GET /api/moderation/actions", apiCfg.middlewareIsModerator(apiCfg.handlerModerationActionsList))
+
+	mux.Handle("POST /api/webhooks", apiCfg.middlewareisAuthed(apiCfg.handlerWebhookEndpointsCreate))
+	mux.Handle("GET /api/webhooks", apiCfg.middlewareisAuthed(apiCfg.handlerWebhookEndpointsList))
+	mux.Handle("DELETE /api/webhooks/{endpointID}", apiCfg.middlewareisAuthed(apiCfg.handlerWebhookEndpointsDelete))
+	mux.Handle("POST /api/webhooks/{endpointID}/enable", apiCfg.middlewareisAuthed(apiCfg.handlerWebhookEndpointsEnable))
+	mux.Handle("GET /api/webhooks/{endpointID}/deliveries", apiCfg.middlewareisAuthed(apiCfg.handlerWebhookDeliveriesList))
 
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.Handle("GET /admin/webhooks/events", apiCfg.middlewareIsAdmin(apiCfg.handlerWebhookEventsList))
+	mux.Handle("POST /admin/webhooks/events/{eventID}/retry", apiCfg.middlewareIsAdmin(apiCfg.handlerWebhookEventRetry))
+
+	mux.Handle("PUT /admin/users/{userID}/moderator", apiCfg.middlewareIsAdmin(apiCfg.handlerUsersSetModerator))
+	mux.Handle("GET /admin/moderation/rules", apiCfg.middlewareIsAdmin(apiCfg.handlerModerationRulesList))
+	mux.Handle("POST /admin/moderation/rules", apiCfg.middlewareIsAdmin(apiCfg.handlerModerationRulesCreate))
+	mux.Handle("DELETE /admin/moderation/rules/{ruleID}", apiCfg.middlewareIsAdmin(apiCfg.handlerModerationRulesDelete))
+	mux.Handle("GET /admin/moderation/flags", apiCfg.middlewareIsAdmin(apiCfg.handlerFlaggedChirpsList))
+	mux.Handle("DELETE /admin/moderation/flags/{chirpID}", apiCfg.middlewareIsAdmin(apiCfg.handlerFlaggedChirpsDismiss))
 
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlePolkaWebhook)
 
+	mux.HandleFunc("GET /.well-known/webfinger", apiCfg.handlerWebFinger)
+	mux.HandleFunc("GET /ap/users/{userID}", apiCfg.handlerAPActor)
+	mux.HandleFunc("POST /ap/users/{userID}/inbox", apiCfg.handlerAPInbox)
+	mux.HandleFunc("GET /ap/users/{userID}/outbox", apiCfg.handlerAPOutbox)
+	mux.HandleFunc("GET /ap/users/{userID}/followers", apiCfg.handlerAPFollowers)
+	mux.HandleFunc("GET /ap/chirps/{chirpID}", apiCfg.handlerAPNote)
+
+	go apiCfg.runModerationReload(context.Background(), moderationReloadInterval)
+	go apiCfg.runSubscriptionExpiry(context.Background(), subscriptionExpiryInterval)
+	go apiCfg.runUserStatusSweep(context.Background(), userStatusTTL)
+	go apiCfg.runChirpScheduler(context.Background(), chirpSchedulerInterval)
+	go apiCfg.runChirpPurge(context.Background(), chirpPurgeInterval)
+	go apiCfg.runMediaCleanup(context.Background(), mediaCleanupInterval)
+	go apiCfg.runWebhookDeliveries(context.Background(), webhookDeliveryInterval)
+	go apiCfg.runAPDeliveries(context.Background(), apDeliveryInterval)
+	go apiCfg.runLinkPreviews(context.Background(), linkPreviewInterval)
+	go apiCfg.runEventListener(context.Background(), dbURL)
+	go apiCfg.runChirpEventPrune(context.Background(), chirpEventPruneInterval)
+
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: mux,
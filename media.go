@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaUploadBytes = 5 << 20
+	mediaThumbnailSize  = 400
+	maxChirpMedia       = 4
+	mediaCacheControl   = "public, max-age=31536000, immutable"
+
+	defaultMediaDir  = "./media"
+	blobStoreTimeout = 30 * time.Second
+)
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func mediaFromDB(dbMedia database.Medium) Media {
+	return Media{
+		ID:           dbMedia.ID,
+		CreatedAt:    dbMedia.CreatedAt,
+		ContentType:  dbMedia.ContentType,
+		Width:        dbMedia.Width,
+		Height:       dbMedia.Height,
+		URL:          "/api/media/" + dbMedia.ID.String(),
+		ThumbnailURL: "/api/media/" + dbMedia.ID.String() + "/thumbnail",
+	}
+}
+
+// checkChirpMedia makes sure a new chirp references at most maxChirpMedia
+// distinct uploads, all owned by its author.
+func checkChirpMedia(ctx context.Context, qtx *database.Queries, userID uuid.UUID, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+	if len(mediaIDs) > maxChirpMedia {
+		return &chirpRequestError{http.StatusBadRequest, "Chirps can have at most 4 images"}
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range mediaIDs {
+		if seen[id] {
+			return &chirpRequestError{http.StatusBadRequest, "Duplicate media id"}
+		}
+		seen[id] = true
+	}
+
+	owned, err := qtx.LockMediaOwnedByUser(ctx, database.LockMediaOwnedByUserParams{
+		UserID: userID,
+		Ids:    mediaIDs,
+	})
+	if err != nil {
+		return err
+	}
+	if len(owned) != len(mediaIDs) {
+		return &chirpRequestError{http.StatusBadRequest, "Unknown media id"}
+	}
+	return nil
+}
+
+// blobStoreFromEnv picks the blob store from MEDIA_STORAGE: "local" (the
+// default) keeps files under MEDIA_DIR, "s3" uses an S3-compatible bucket.
+func blobStoreFromEnv() (storage.BlobStore, error) {
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = defaultMediaDir
+		}
+		return storage.NewLocalStore(dir)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}, blobStoreTimeout)
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORAGE backend %q", backend)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+)
+
+const (
+	mediaCleanupInterval = time.Hour
+	// unattachedMediaAge is how long an upload may wait to be attached to a
+	// chirp before it is deleted
+	unattachedMediaAge    = 24 * time.Hour
+	mediaCleanupBatchSize = 100
+)
+
+// runMediaCleanup periodically deletes media that isn't attached to any
+// chirp, either because it never was or because its chirps were purged,
+// along with its blobs.
+func (cfg *apiConfig) runMediaCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			deleted, err := cfg.db.DeleteUnattachedMedia(ctx, database.DeleteUnattachedMediaParams{
+				MinAgeSeconds: int32(unattachedMediaAge.Seconds()),
+				BatchSize:     mediaCleanupBatchSize,
+			})
+			if err != nil {
+				log.Printf("Error deleting unattached media: %s", err)
+				break
+			}
+			// the rows are gone, so a blob that fails to delete is only logged
+			for _, dbMedia := range deleted {
+				for _, key := range []string{dbMedia.StorageKey, dbMedia.ThumbnailKey} {
+					if err := cfg.blobs.Delete(ctx, key); err != nil {
+						log.Printf("Couldn't delete blob %s: %s", key, err)
+					}
+				}
+			}
+			if len(deleted) > 0 {
+				log.Printf("Deleted %d unattached media", len(deleted))
+			}
+			if len(deleted) < mediaCleanupBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
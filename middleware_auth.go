@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/bontaramsonta/go-chirpy/internal/auth"
+	"github.com/google/uuid"
 )
 
 func (cfg *apiConfig) middlewareisAuthed(next http.HandlerFunc) http.Handler {
@@ -21,11 +24,59 @@ func (cfg *apiConfig) middlewareisAuthed(next http.HandlerFunc) http.Handler {
 			return
 		}
 
+		// tokens outlive suspensions and bans, so check the account is still in good standing
+		status, err := cfg.userStatus(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid credentials", err)
+			return
+		}
+		if restriction := status.restriction(time.Now()); restriction != "" {
+			respondWithError(w, http.StatusForbidden, restriction, nil)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), auth.UserIDKey, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// middlewareOptionalAuth authenticates requests that carry a bearer token and
+// lets anonymous requests through. Handlers read the caller with viewerID.
+func (cfg *apiConfig) middlewareOptionalAuth(next http.HandlerFunc) http.Handler {
+	authed := cfg.middlewareisAuthed(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authed.ServeHTTP(w, r)
+	})
+}
+
+// viewerID returns the authenticated caller, if there is one.
+func viewerID(r *http.Request) uuid.NullUUID {
+	userID, ok := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+	return uuid.NullUUID{UUID: userID, Valid: ok}
+}
+
+func (cfg *apiConfig) middlewareIsModerator(next http.HandlerFunc) http.Handler {
+	return cfg.middlewareisAuthed(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(auth.UserIDKey).(uuid.UUID)
+
+		user, err := cfg.db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid credentials", err)
+			return
+		}
+		if !user.IsModerator {
+			respondWithError(w, http.StatusForbidden, "Moderator access required", nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (cfg *apiConfig) middlewareCheckRefreshToken(next http.HandlerFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		refreshToken, err := auth.GetBearerToken(r.Header)
@@ -46,3 +97,20 @@ func (cfg *apiConfig) middlewareCheckRefreshToken(next http.HandlerFunc) http.Ha
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func (cfg *apiConfig) middlewareIsAdmin(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, err := auth.GetAPIKey(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key", err)
+			return
+		}
+		// an unset admin key disables the admin API entirely
+		if cfg.adminKey == "" || apiKey != cfg.adminKey {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key", errors.New("admin API key mismatch"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
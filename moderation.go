@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/moderation"
+)
+
+// moderation rules are edited through the admin API, which reloads them
+// straight away; the periodic reload picks up edits made on other instances.
+const moderationReloadInterval = 30 * time.Second
+
+func (cfg *apiConfig) reloadModerationRules(ctx context.Context) error {
+	dbRules, err := cfg.db.ListModerationRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]moderation.Rule, 0, len(dbRules))
+	for _, dbRule := range dbRules {
+		rules = append(rules, moderation.Rule{
+			Term:   dbRule.Term,
+			Action: moderation.Action(dbRule.Action),
+		})
+	}
+	cfg.wordList.Replace(rules)
+	return nil
+}
+
+func (cfg *apiConfig) runModerationReload(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.reloadModerationRules(ctx); err != nil {
+			log.Printf("Error reloading moderation rules: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// flaggedTerms returns the terms that caused a chirp to be flagged for review.
+func flaggedTerms(res moderation.Result) []string {
+	terms := []string{}
+	for _, match := range res.Matches {
+		if match.Action == moderation.ActionFlag {
+			terms = append(terms, match.Term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationTypeRechirp = "rechirp"
+	NotificationTypeQuote   = "quote"
+
+	defaultNotificationsLimit = 20
+	maxNotificationsLimit     = 100
+
+	// NotificationEvent is the stream event type for new and updated
+	// notifications, announced on notificationsChannel
+	NotificationEvent            = "notification"
+	notificationsChannel         = "notifications"
+	notificationStreamBufferSize = 64
+)
+
+type Notification struct {
+	ID         uuid.UUID  `json:"id"`
+	Type       string     `json:"type"`
+	ChirpID    *int32     `json:"chirp_id"`
+	ActorID    *uuid.UUID `json:"actor_id"`
+	ActorCount int32      `json:"actor_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReadAt     *time.Time `json:"read_at"`
+}
+
+func notificationFromDB(dbNotification database.Notification) Notification {
+	notification := Notification{
+		ID:         dbNotification.ID,
+		Type:       dbNotification.Type,
+		ActorCount: dbNotification.ActorCount,
+		CreatedAt:  dbNotification.CreatedAt,
+		UpdatedAt:  dbNotification.UpdatedAt,
+	}
+	if dbNotification.ChirpID.Valid {
+		notification.ChirpID = &dbNotification.ChirpID.Int32
+	}
+	if dbNotification.ActorID.Valid {
+		notification.ActorID = &dbNotification.ActorID.UUID
+	}
+	if dbNotification.ReadAt.Valid {
+		notification.ReadAt = &dbNotification.ReadAt.Time
+	}
+	return notification
+}
+
+// notify records that actor did something to recipient's chirp, using qtx so
+// the notification commits with the action itself. While unread, repeats of
+// the same type on the same chirp coalesce into one notification that counts
+// distinct actors. Self-actions, actors the recipient blocked or muted, and
+// actors already notified about the chirp are skipped.
+func notify(ctx context.Context, qtx *database.Queries, recipientID uuid.UUID, notificationType string, chirpID int32, actorID uuid.UUID) error {
+	return qtx.CreateNotification(ctx, database.CreateNotificationParams{
+		RecipientID: recipientID,
+		Type:        notificationType,
+		ChirpID:     sql.NullInt32{Int32: chirpID, Valid: true},
+		ActorID:     actorID,
+	})
+}
+
+// publishNotification loads the notification named by a NOTIFY payload and
+// publishes it to cfg.notificationStream for its recipient's connections.
+func (cfg *apiConfig) publishNotification(ctx context.Context, payload string) {
+	id, err := uuid.Parse(payload)
+	if err != nil {
+		log.Printf("Invalid notification payload %q", payload)
+		return
+	}
+	dbNotification, err := cfg.db.GetNotificationByID(ctx, id)
+	if err != nil {
+		log.Printf("Error loading notification %s: %s", id, err)
+		return
+	}
+	data, err := json.Marshal(notificationFromDB(dbNotification))
+	if err != nil {
+		log.Printf("Error encoding notification %s: %s", id, err)
+		return
+	}
+	cfg.notificationStream.Publish(stream.Event{
+		Type:   NotificationEvent,
+		UserID: dbNotification.RecipientID,
+		Data:   data,
+	})
+}
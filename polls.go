@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/chirptext"
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// Poll is attached to a chirp. Votes are only filled in once the caller has
+// voted or the poll has closed.
+type Poll struct {
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	Options    []PollOption `json:"options"`
+	TotalVotes *int64       `json:"total_votes,omitempty"`
+	MyVote     *int32       `json:"my_vote,omitempty"`
+}
+
+type PollOption struct {
+	ID    int32  `json:"id"`
+	Body  string `json:"body"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type pollParams struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// createPoll validates and stores the poll for a chirp that opens at opensAt,
+// which is its publish time for scheduled chirps.
+func (cfg *apiConfig) createPoll(ctx context.Context, qtx *database.Queries, chirpID int32, opensAt time.Time, params pollParams) error {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return &chirpRequestError{http.StatusBadRequest, "Polls need between 2 and 4 options"}
+	}
+	if params.ClosesAt.Before(opensAt.Add(minPollDuration)) {
+		return &chirpRequestError{http.StatusBadRequest, "Poll closes_at is too soon"}
+	}
+	if params.ClosesAt.After(opensAt.Add(maxPollDuration)) {
+		return &chirpRequestError{http.StatusBadRequest, "Poll closes_at is too far in the future"}
+	}
+
+	seen := map[string]bool{}
+	options := make([]string, 0, len(params.Options))
+	for _, option := range params.Options {
+		// options are normalized and counted like chirps
+		option = chirptext.Normalize(option)
+		if option == "" {
+			return &chirpRequestError{http.StatusBadRequest, "Poll options can't be empty"}
+		}
+		if chirptext.Length(option) > maxPollOptionLength {
+			return &chirpRequestError{http.StatusBadRequest, "Poll option is too long"}
+		}
+		if seen[strings.ToLower(option)] {
+			return &chirpRequestError{http.StatusBadRequest, "Poll options must be unique"}
+		}
+		seen[strings.ToLower(option)] = true
+
+		res := cfg.moderator.Moderate(option)
+		if res.Rejected {
+			return &chirpRequestError{http.StatusBadRequest, "Poll option contains prohibited content"}
+		}
+		options = append(options, res.Body)
+	}
+
+	if err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: params.ClosesAt.UTC(),
+	}); err != nil {
+		return err
+	}
+	for i, option := range options {
+		if err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Body:     option,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPolls returns the polls attached to the given chirps, keyed by chirp id,
+// with tallies hidden according to what viewer may see.
+func (cfg *apiConfig) loadPolls(ctx context.Context, viewer uuid.NullUUID, chirpIDs []int32) (map[int32]*Poll, error) {
+	rows, err := cfg.db.GetPollTallies(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return map[int32]*Poll{}, nil
+	}
+
+	myVotes := map[int32]int32{}
+	if viewer.Valid {
+		votes, err := cfg.db.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			myVotes[vote.ChirpID] = vote.OptionID
+		}
+	}
+
+	now := time.Now().UTC()
+	polls := map[int32]*Poll{}
+	tallies := map[int32][]int64{}
+	for _, row := range rows {
+		poll, ok := polls[row.ChirpID]
+		if !ok {
+			poll = &Poll{
+				ClosesAt: row.ClosesAt,
+				Closed:   !row.ClosesAt.After(now),
+				Options:  []PollOption{},
+			}
+			if optionID, voted := myVotes[row.ChirpID]; voted {
+				poll.MyVote = &optionID
+			}
+			polls[row.ChirpID] = poll
+		}
+		poll.Options = append(poll.Options, PollOption{ID: row.ID, Body: row.Body})
+		tallies[row.ChirpID] = append(tallies[row.ChirpID], row.Votes)
+	}
+
+	for chirpID, poll := range polls {
+		if !poll.Closed && poll.MyVote == nil {
+			continue
+		}
+		total := int64(0)
+		for i, votes := range tallies[chirpID] {
+			poll.Options[i].Votes = &votes
+			total += votes
+		}
+		poll.TotalVotes = &total
+	}
+	return polls, nil
+}
@@ -0,0 +1,73 @@
+-- name: GetAPKey :one
+SELECT * FROM ap_keys
+WHERE user_id = $1;
+
+-- name: CreateAPKey :exec
+INSERT INTO ap_keys (user_id, private_key, public_key)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: AddAPFollower :exec
+INSERT INTO ap_followers (user_id, actor_id, inbox, shared_inbox, follow_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, actor_id) DO UPDATE SET
+    inbox = EXCLUDED.inbox,
+    shared_inbox = EXCLUDED.shared_inbox,
+    follow_id = EXCLUDED.follow_id;
+
+-- name: RemoveAPFollower :execrows
+DELETE FROM ap_followers
+WHERE user_id = $1 AND actor_id = $2;
+
+-- name: CountAPFollowers :one
+SELECT COUNT(*) FROM ap_followers
+WHERE user_id = $1;
+
+-- name: EnqueueAPFollowerDeliveries :execrows
+-- followers on the same server share one delivery to its shared inbox
+INSERT INTO ap_deliveries (id, user_id, inbox, payload)
+SELECT gen_random_uuid(), sqlc.arg('user_id')::uuid, inboxes.inbox, sqlc.arg('payload')::jsonb
+FROM (
+    SELECT DISTINCT COALESCE(ap_followers.shared_inbox, ap_followers.inbox) AS inbox
+    FROM ap_followers
+    WHERE ap_followers.user_id = sqlc.arg('user_id')::uuid
+) AS inboxes;
+
+-- name: EnqueueAPDelivery :exec
+INSERT INTO ap_deliveries (id, user_id, inbox, payload)
+VALUES (gen_random_uuid(), $1, $2, $3);
+
+-- name: ClaimDueAPDeliveries :many
+WITH due AS (
+    SELECT ap_deliveries.id FROM ap_deliveries
+    WHERE ap_deliveries.status = 'pending'
+        AND ap_deliveries.next_attempt_at <= NOW()
+    ORDER BY ap_deliveries.next_attempt_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE ap_deliveries SET
+    attempts = ap_deliveries.attempts + 1,
+    next_attempt_at = NOW() + (sqlc.arg('lease_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+FROM due
+WHERE ap_deliveries.id = due.id
+RETURNING ap_deliveries.*;
+
+-- name: MarkAPDeliverySucceeded :exec
+UPDATE ap_deliveries SET
+    status = 'succeeded',
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkAPDeliveryFailed :exec
+UPDATE ap_deliveries SET
+    status = sqlc.arg('status'),
+    last_status_code = sqlc.narg('last_status_code'),
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = NOW() + (sqlc.arg('retry_after_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id');
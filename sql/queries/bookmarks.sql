@@ -0,0 +1,31 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnbookmarkChirp :execrows
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarksByUserID :many
+SELECT bookmarks.created_at AS bookmarked_at, sqlc.embed(chirps)
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+JOIN users ON users.id = chirps.user_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+    -- the cursor is (created_at, chirp_id), so bookmarks made at the same
+    -- moment aren't skipped between pages
+    AND (sqlc.narg('before')::timestamp IS NULL
+        OR bookmarks.created_at < sqlc.narg('before')
+        OR (bookmarks.created_at = sqlc.narg('before') AND bookmarks.chirp_id < sqlc.narg('before_id')::int))
+    AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.arg('user_id') AND blocks.blocked_id = chirps.user_id
+    )
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::int[]);
@@ -0,0 +1,33 @@
+-- name: CreateChirpEvent :exec
+-- the notification is only delivered once the surrounding transaction
+-- commits, and tells listeners there is an event to sequence
+WITH event AS (
+    INSERT INTO chirp_events (type, chirp_id, user_id, payload)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id
+)
+SELECT pg_notify('chirp_events', event.id::text) FROM event;
+
+-- name: LockChirpEventSequencer :exec
+-- held until the transaction ends, so sequenced events commit in seq order
+SELECT pg_advisory_xact_lock(hashtext('chirp_event_seq'));
+
+-- name: SequenceChirpEvents :execrows
+UPDATE chirp_events SET seq = nextval('chirp_event_seq')
+WHERE seq IS NULL;
+
+-- name: NotifyChirpEventsSequenced :exec
+SELECT pg_notify('chirp_events_sequenced', '');
+
+-- name: GetChirpEventsAfter :many
+SELECT * FROM chirp_events
+WHERE seq > sqlc.arg('seq')::bigint
+ORDER BY seq
+LIMIT sqlc.arg('limit');
+
+-- name: GetLatestChirpEventSeq :one
+SELECT COALESCE(MAX(seq), 0)::bigint FROM chirp_events;
+
+-- name: PruneChirpEvents :execrows
+DELETE FROM chirp_events
+WHERE created_at < NOW() - (sqlc.arg('retention_seconds')::int * INTERVAL '1 second');
@@ -0,0 +1,56 @@
+-- name: AddChirpLink :exec
+WITH preview AS (
+    INSERT INTO link_previews (url)
+    VALUES (sqlc.arg('url'))
+    ON CONFLICT (url) DO NOTHING
+)
+INSERT INTO chirp_links (chirp_id, position, url)
+VALUES (sqlc.arg('chirp_id'), sqlc.arg('position'), sqlc.arg('url'));
+
+-- name: DeleteChirpLinks :exec
+DELETE FROM chirp_links
+WHERE chirp_id = $1;
+
+-- name: GetLinksForChirps :many
+SELECT chirp_links.chirp_id, sqlc.embed(link_previews)
+FROM chirp_links
+JOIN link_previews ON link_previews.url = chirp_links.url
+WHERE chirp_links.chirp_id = ANY(sqlc.arg('chirp_ids')::int[])
+ORDER BY chirp_links.chirp_id, chirp_links.position;
+
+-- name: ClaimDueLinkPreviews :many
+WITH due AS (
+    SELECT link_previews.url FROM link_previews
+    WHERE link_previews.status = 'pending'
+        AND link_previews.next_attempt_at <= NOW()
+    ORDER BY link_previews.next_attempt_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE link_previews SET
+    attempts = link_previews.attempts + 1,
+    next_attempt_at = NOW() + (sqlc.arg('lease_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+FROM due
+WHERE link_previews.url = due.url
+RETURNING link_previews.*;
+
+-- name: MarkLinkPreviewFetched :exec
+UPDATE link_previews SET
+    status = 'fetched',
+    title = $2,
+    description = $3,
+    image_url = $4,
+    site_name = $5,
+    last_error = NULL,
+    fetched_at = NOW(),
+    updated_at = NOW()
+WHERE url = $1;
+
+-- name: MarkLinkPreviewFailed :exec
+UPDATE link_previews SET
+    status = sqlc.arg('status'),
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = NOW() + (sqlc.arg('retry_after_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE url = sqlc.arg('url');
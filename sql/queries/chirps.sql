@@ -1,18 +1,125 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (user_id, body)
-VALUES ($1, $2)
+INSERT INTO chirps (user_id, body, publish_at, quote_of)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetAllChirps :many
-SELECT id, user_id, body, created_at, updated_at FROM chirps;
+-- newest first; a NULL limit returns every chirp
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.narg('viewer_id') AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.narg('limit')::int;
 
 -- name: GetChirpsByAuthorID :many
-SELECT id, user_id, body, created_at, updated_at FROM chirps
-WHERE user_id = $1;
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.narg('viewer_id') AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id
+    )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.narg('limit')::int;
+
+-- name: CountChirpsByAuthorID :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND hidden_at IS NULL AND deleted_at IS NULL AND publish_at IS NULL;
 
 -- name: GetChirpByID :one
-SELECT id, user_id, body, created_at, updated_at FROM chirps
-WHERE id = $1;
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetDeletedChirpByID :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL;
 
 -- name: DeleteChirp :exec
-DELETE FROM chirps WHERE id = $1;
+UPDATE chirps SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreDeletedChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = sqlc.arg('id')
+    AND deleted_at > NOW() - (sqlc.arg('window_seconds')::int * INTERVAL '1 second')
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < NOW() - (sqlc.arg('window_seconds')::int * INTERVAL '1 second');
+
+-- name: UpdateChirp :one
+UPDATE chirps SET body = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: CountRecentChirpsByAuthor :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND created_at > NOW() - INTERVAL '1 hour';
+
+-- name: HideChirp :one
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: RestoreChirp :one
+UPDATE chirps SET hidden_at = NULL WHERE id = $1
+RETURNING *;
+
+-- name: GetScheduledChirpsByAuthorID :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND publish_at IS NOT NULL AND deleted_at IS NULL
+ORDER BY publish_at;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND publish_at IS NOT NULL;
+
+-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE publish_at IS NOT NULL AND publish_at <= NOW() AND deleted_at IS NULL
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE chirps SET publish_at = NULL, created_at = NOW(), updated_at = NOW()
+FROM due
+WHERE chirps.id = due.id
+RETURNING chirps.*;
+
+-- name: GetShareableChirpByID :one
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.id = sqlc.arg('id') AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg('user_id')
+    );
+
+-- name: GetQuotedChirps :many
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.id = ANY(sqlc.arg('ids')::int[])
+    AND chirps.hidden_at IS NULL AND chirps.deleted_at IS NULL AND chirps.publish_at IS NULL
+    AND users.banned_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.blocker_id = sqlc.narg('viewer_id') AND blocks.blocked_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id
+    );
@@ -0,0 +1,57 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, user_a_id, user_b_id)
+VALUES (gen_random_uuid(), LEAST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid), GREATEST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid))
+ON CONFLICT (user_a_id, user_b_id) DO NOTHING
+RETURNING *;
+
+-- name: GetConversationBetween :one
+SELECT * FROM conversations
+WHERE user_a_id = LEAST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid)
+    AND user_b_id = GREATEST(sqlc.arg('user_id')::uuid, sqlc.arg('other_id')::uuid);
+
+-- name: GetConversationForUser :one
+SELECT * FROM conversations
+WHERE id = sqlc.arg('id') AND (user_a_id = sqlc.arg('user_id') OR user_b_id = sqlc.arg('user_id'));
+
+-- name: GetConversationsForUser :many
+SELECT sqlc.embed(conversations), (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> sqlc.arg('user_id')
+        AND messages.read_at IS NULL
+)::bigint AS unread_count
+FROM conversations
+WHERE (conversations.user_a_id = sqlc.arg('user_id') OR conversations.user_b_id = sqlc.arg('user_id'))
+    AND (sqlc.narg('before')::timestamp IS NULL
+        OR conversations.updated_at < sqlc.narg('before')
+        OR (conversations.updated_at = sqlc.narg('before') AND conversations.id < sqlc.narg('before_id')::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = NOW() WHERE id = $1;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+    AND (sqlc.narg('before')::timestamp IS NULL
+        OR created_at < sqlc.narg('before')
+        OR (created_at = sqlc.narg('before') AND id < sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLatestMessages :many
+SELECT DISTINCT ON (conversation_id) * FROM messages
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_id, created_at DESC, id DESC;
+
+-- name: MarkConversationRead :exec
+UPDATE messages SET read_at = NOW()
+WHERE conversation_id = sqlc.arg('conversation_id')
+    AND sender_id <> sqlc.arg('user_id')
+    AND read_at IS NULL;
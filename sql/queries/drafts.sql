@@ -0,0 +1,27 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, user_id, body)
+VALUES (gen_random_uuid(), $1, $2)
+RETURNING *;
+
+-- name: GetDraftsByUserID :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: GetDraftByID :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: LockDraftByID :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: UpdateDraft :one
+UPDATE drafts SET body = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2;
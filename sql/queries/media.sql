@@ -0,0 +1,50 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, user_id, content_type, width, height, size_bytes, storage_key, thumbnail_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetMediaByID :one
+SELECT * FROM media WHERE id = $1;
+
+-- name: LockUserMediaUsage :exec
+-- serializes a user's uploads, so each one's quota check sees the others
+SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE;
+
+-- name: GetMediaUsage :one
+SELECT
+    COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 hour') AS recent_uploads,
+    COALESCE(SUM(size_bytes), 0)::bigint AS storage_bytes
+FROM media
+WHERE user_id = $1;
+
+-- name: LockMediaOwnedByUser :many
+-- locks the media being attached, so DeleteUnattachedMedia can't remove it
+-- before the chirp_media rows are committed
+SELECT id FROM media
+WHERE user_id = sqlc.arg('user_id') AND id = ANY(sqlc.arg('ids')::uuid[])
+FOR UPDATE;
+
+-- name: AttachMediaToChirp :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES ($1, $2, $3);
+
+-- name: GetMediaForChirps :many
+SELECT chirp_media.chirp_id, sqlc.embed(media)
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg('chirp_ids')::int[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: DeleteUnattachedMedia :many
+-- uploads that were never attached to a chirp, or whose chirps were purged.
+-- Media being attached right now is skipped, since attaching locks the row.
+DELETE FROM media
+WHERE id IN (
+    SELECT m.id FROM media m
+    WHERE m.created_at < NOW() - (sqlc.arg('min_age_seconds')::int * INTERVAL '1 second')
+        AND NOT EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.media_id = m.id)
+    ORDER BY m.created_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING storage_key, thumbnail_key;
@@ -0,0 +1,27 @@
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY term;
+
+-- name: UpsertModerationRule :one
+INSERT INTO moderation_rules (id, term, action)
+VALUES (gen_random_uuid(), $1, $2)
+ON CONFLICT (term) DO UPDATE SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules WHERE id = $1;
+
+-- name: FlagChirp :exec
+INSERT INTO chirp_flags (chirp_id, terms)
+VALUES ($1, $2)
+ON CONFLICT (chirp_id) DO UPDATE SET terms = EXCLUDED.terms, created_at = NOW();
+
+-- name: ListFlaggedChirps :many
+SELECT sqlc.embed(chirps), chirp_flags.terms, chirp_flags.created_at AS flagged_at
+FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE chirps.deleted_at IS NULL
+ORDER BY chirp_flags.created_at;
+
+-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags WHERE chirp_id = $1;
@@ -0,0 +1,63 @@
+-- name: CreateNotification :exec
+-- listeners are told about new and coalesced notifications once the
+-- surrounding transaction commits. An actor is only counted once per
+-- recipient, type and chirp, read notifications included, so repeating an
+-- action doesn't notify again.
+WITH eligible AS (
+    SELECT 1
+    WHERE sqlc.arg('recipient_id')::uuid <> sqlc.arg('actor_id')::uuid
+        AND NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE blocks.blocker_id = sqlc.arg('recipient_id') AND blocks.blocked_id = sqlc.arg('actor_id')
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = sqlc.arg('recipient_id') AND mutes.muted_id = sqlc.arg('actor_id')
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM notification_actors
+            JOIN notifications ON notifications.id = notification_actors.notification_id
+            WHERE notifications.recipient_id = sqlc.arg('recipient_id')
+                AND notifications.type = sqlc.arg('type')
+                AND notifications.chirp_id = sqlc.narg('chirp_id')
+                AND notification_actors.actor_id = sqlc.arg('actor_id')
+        )
+), notification AS (
+    INSERT INTO notifications (id, recipient_id, type, chirp_id, actor_id)
+    SELECT gen_random_uuid(), sqlc.arg('recipient_id')::uuid, sqlc.arg('type')::text, sqlc.narg('chirp_id')::int, sqlc.arg('actor_id')::uuid
+    FROM eligible
+    ON CONFLICT (recipient_id, type, chirp_id) WHERE read_at IS NULL
+    DO UPDATE SET actor_id = EXCLUDED.actor_id,
+        actor_count = notifications.actor_count + 1,
+        updated_at = NOW()
+    RETURNING id
+), actor AS (
+    INSERT INTO notification_actors (notification_id, actor_id)
+    SELECT notification.id, sqlc.arg('actor_id')::uuid FROM notification
+    ON CONFLICT DO NOTHING
+)
+SELECT pg_notify('notifications', notification.id::text) FROM notification;
+
+-- name: GetNotificationByID :one
+SELECT * FROM notifications WHERE id = $1;
+
+-- name: GetNotificationsByRecipient :many
+SELECT * FROM notifications
+WHERE recipient_id = sqlc.arg('recipient_id')
+    AND (sqlc.narg('before')::timestamp IS NULL
+        OR updated_at < sqlc.narg('before')
+        OR (updated_at = sqlc.narg('before') AND id < sqlc.narg('before_id')::uuid))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE recipient_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND recipient_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE recipient_id = $1 AND read_at IS NULL;
@@ -0,0 +1,33 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, closes_at)
+VALUES ($1, $2);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, body)
+VALUES ($1, $2, $3);
+
+-- name: GetPollByChirpID :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPollOptionByID :one
+SELECT * FROM poll_options WHERE id = $1 AND chirp_id = $2;
+
+-- name: CreatePollVote :one
+INSERT INTO poll_votes (chirp_id, user_id, option_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (chirp_id, user_id) DO NOTHING
+RETURNING *;
+
+-- name: GetPollTallies :many
+SELECT poll_options.id, poll_options.chirp_id, poll_options.body, polls.closes_at,
+    COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+JOIN polls ON polls.chirp_id = poll_options.chirp_id
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::int[])
+GROUP BY poll_options.id, polls.closes_at
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: GetPollVotesByUser :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::int[]);
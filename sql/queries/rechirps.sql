@@ -0,0 +1,13 @@
+-- name: Rechirp :execrows
+INSERT INTO rechirps (user_id, chirp_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: Unrechirp :execrows
+DELETE FROM rechirps WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetRechirpCounts :many
+SELECT chirp_id, COUNT(*) AS rechirp_count
+FROM rechirps
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::int[])
+GROUP BY chirp_id;
@@ -0,0 +1,34 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, chirp_id, reporter_id, reason)
+VALUES (gen_random_uuid(), $1, $2, $3)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING *;
+
+-- name: GetReportByID :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: ListOpenReports :many
+SELECT sqlc.embed(reports), sqlc.embed(chirps)
+FROM reports
+JOIN chirps ON chirps.id = reports.chirp_id
+WHERE reports.status = 'open' AND chirps.deleted_at IS NULL
+ORDER BY reports.created_at;
+
+-- name: ResolveReport :one
+UPDATE reports SET status = $2, resolved_by = $3, resolved_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReportsForChirp :execrows
+UPDATE reports SET status = $2, resolved_by = $3, resolved_at = NOW()
+WHERE chirp_id = $1 AND status = 'open';
+
+-- name: RecordModerationAction :one
+INSERT INTO moderation_actions (id, moderator_id, action, target_type, target_id, reason)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT $1;
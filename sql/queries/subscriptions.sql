@@ -0,0 +1,36 @@
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions (user_id, plan, status, current_period_end)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE SET
+    plan = EXCLUDED.plan,
+    status = EXCLUDED.status,
+    current_period_end = EXCLUDED.current_period_end,
+    cancelled_at = NULL,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetSubscriptionByUserID :one
+SELECT * FROM subscriptions WHERE user_id = $1;
+
+-- name: CancelSubscription :one
+UPDATE subscriptions SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND status IN ('active', 'past_due')
+RETURNING *;
+
+-- name: MarkSubscriptionPastDue :one
+UPDATE subscriptions SET status = 'past_due', updated_at = NOW()
+WHERE user_id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: EndSubscription :exec
+UPDATE subscriptions SET status = 'expired', current_period_end = LEAST(current_period_end, NOW()), updated_at = NOW()
+WHERE user_id = $1 AND status <> 'expired';
+
+-- name: ExpireSubscriptions :execrows
+WITH expired AS (
+    UPDATE subscriptions SET status = 'expired', updated_at = NOW()
+    WHERE status <> 'expired' AND current_period_end <= NOW()
+    RETURNING user_id
+)
+UPDATE users SET is_chirpy_red = FALSE, updated_at = NOW()
+WHERE id IN (SELECT user_id FROM expired);
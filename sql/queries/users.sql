@@ -16,3 +16,54 @@ SELECT * FROM users WHERE email = $1;
 -- name: UpgradeUser :one
 UPDATE users SET is_chirpy_red = TRUE, updated_at = NOW() WHERE id = $1
 RETURNING *;
+
+-- name: DowngradeUser :one
+UPDATE users SET is_chirpy_red = FALSE, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: GetUserPlan :one
+SELECT (CASE WHEN users.is_chirpy_red THEN COALESCE(subscriptions.plan, 'red') ELSE 'free' END)::text AS plan
+FROM users
+LEFT JOIN subscriptions ON subscriptions.user_id = users.id
+WHERE users.id = $1;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: SetUserModerator :one
+UPDATE users SET is_moderator = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :one
+UPDATE users SET suspended_until = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users SET banned_at = NOW(), updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: ReinstateUser :one
+UPDATE users SET banned_at = NULL, suspended_until = NULL, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: PinChirp :exec
+UPDATE users SET pinned_chirp_id = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: UnpinChirp :execrows
+UPDATE users SET pinned_chirp_id = NULL, updated_at = NOW()
+WHERE id = $1 AND pinned_chirp_id = $2;
+
+-- name: GetPinnedChirpID :one
+SELECT pinned_chirp_id FROM users WHERE id = $1;
+
+-- name: GetUserAvatarVersionForUpdate :one
+-- locks the user so avatar changes are serialized
+SELECT avatar_version FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: SetUserAvatar :one
+UPDATE users SET avatar_version = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserDMSettings :one
+UPDATE users SET dm_policy = $2, dm_filter = $3, updated_at = NOW() WHERE id = $1
+RETURNING *;
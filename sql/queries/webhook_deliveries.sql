@@ -0,0 +1,50 @@
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (id, endpoint_id, event_type, payload)
+SELECT gen_random_uuid(), webhook_endpoints.id, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb
+FROM webhook_endpoints
+WHERE webhook_endpoints.enabled
+    AND sqlc.arg('event_type')::text = ANY (webhook_endpoints.events)
+    AND (sqlc.narg('user_id')::uuid IS NULL OR webhook_endpoints.user_id = sqlc.narg('user_id')::uuid);
+
+-- name: ClaimDueWebhookDeliveries :many
+WITH due AS (
+    SELECT webhook_deliveries.id FROM webhook_deliveries
+    JOIN webhook_endpoints ON webhook_endpoints.id = webhook_deliveries.endpoint_id
+    WHERE webhook_deliveries.status = 'pending'
+        AND webhook_deliveries.next_attempt_at <= NOW()
+        AND webhook_endpoints.enabled
+    ORDER BY webhook_deliveries.next_attempt_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE OF webhook_deliveries SKIP LOCKED
+)
+UPDATE webhook_deliveries SET
+    attempts = webhook_deliveries.attempts + 1,
+    next_attempt_at = NOW() + (sqlc.arg('lease_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+FROM due
+WHERE webhook_deliveries.id = due.id
+RETURNING webhook_deliveries.*;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries SET
+    status = 'succeeded',
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries SET
+    status = sqlc.arg('status'),
+    last_status_code = sqlc.narg('last_status_code'),
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = NOW() + (sqlc.arg('retry_after_seconds')::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id');
+
+-- name: GetWebhookDeliveriesByEndpointID :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
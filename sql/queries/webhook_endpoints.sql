@@ -0,0 +1,36 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, user_id, url, secret, events)
+VALUES (gen_random_uuid(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetWebhookEndpointByID :one
+SELECT * FROM webhook_endpoints WHERE id = $1;
+
+-- name: GetWebhookEndpointsByUserID :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints WHERE id = $1;
+
+-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints SET enabled = TRUE, failure_count = 0, disabled_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ResetWebhookEndpointFailures :exec
+UPDATE webhook_endpoints SET failure_count = 0, updated_at = NOW()
+WHERE id = $1 AND failure_count > 0;
+
+-- name: RecordWebhookEndpointFailure :one
+UPDATE webhook_endpoints SET
+    failure_count = failure_count + 1,
+    enabled = enabled AND failure_count + 1 < sqlc.arg('max_failures')::int,
+    disabled_at = CASE
+        WHEN enabled AND failure_count + 1 >= sqlc.arg('max_failures')::int THEN NOW()
+        ELSE disabled_at
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
@@ -0,0 +1,35 @@
+-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (id, provider, event_id, event_type, payload)
+VALUES (gen_random_uuid(), $1, $2, $3, $4)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEventByProviderID :one
+SELECT * FROM webhook_events
+WHERE provider = $1 AND event_id = $2;
+
+-- name: GetWebhookEventByID :one
+SELECT * FROM webhook_events
+WHERE id = $1;
+
+-- name: ListWebhookEvents :many
+SELECT * FROM webhook_events
+WHERE sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text
+ORDER BY received_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ClaimWebhookEvent :one
+-- a processing event whose lease has run out was abandoned by a crashed or
+-- timed out attempt, and can be claimed again
+UPDATE webhook_events SET status = 'processing', attempts = attempts + 1, updated_at = NOW()
+WHERE id = sqlc.arg('id')
+    AND (
+        status IN ('pending', 'failed')
+        OR (status = 'processing' AND updated_at < NOW() - (sqlc.arg('lease_seconds')::int * INTERVAL '1 second'))
+    )
+RETURNING *;
+
+-- name: FinishWebhookEvent :exec
+-- only the latest claim records an outcome
+UPDATE webhook_events SET status = $2, error = $3, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND attempts = $4;
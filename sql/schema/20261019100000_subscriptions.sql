@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE subscriptions (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL,
+    -- NULL for subscriptions that never expire
+    current_period_end TIMESTAMP DEFAULT NULL,
+    cancelled_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- existing Chirpy Red users had no billing period, so they keep it until
+-- Polka says otherwise
+INSERT INTO subscriptions (user_id, plan, status)
+SELECT id, 'red', 'active'
+FROM users
+WHERE is_chirpy_red;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE subscriptions;
+
+-- +goose StatementEnd
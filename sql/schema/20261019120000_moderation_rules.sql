@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE moderation_rules (
+    id UUID PRIMARY KEY,
+    term TEXT NOT NULL UNIQUE,
+    action TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO moderation_rules (id, term, action)
+VALUES
+    (gen_random_uuid(), 'kerfuffle', 'mask'),
+    (gen_random_uuid(), 'sharbert', 'mask'),
+    (gen_random_uuid(), 'fornax', 'mask');
+
+CREATE TABLE chirp_flags (
+    chirp_id INTEGER PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    terms TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_flags;
+
+DROP TABLE moderation_rules;
+
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN suspended_until TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP DEFAULT NULL;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    chirp_id INTEGER NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    moderator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE moderation_actions;
+
+DROP TABLE reports;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
+
+ALTER TABLE users
+DROP COLUMN suspended_until,
+DROP COLUMN is_moderator;
+
+-- +goose StatementEnd
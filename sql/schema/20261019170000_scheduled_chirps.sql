@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN publish_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at)
+WHERE publish_at IS NOT NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM chirps WHERE publish_at IS NOT NULL;
+
+ALTER TABLE chirps
+DROP COLUMN publish_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE polls (
+    chirp_id INTEGER PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options (
+    id SERIAL PRIMARY KEY,
+    chirp_id INTEGER NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    UNIQUE (chirp_id, position)
+);
+
+CREATE TABLE poll_votes (
+    chirp_id INTEGER NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    option_id INTEGER NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (chirp_id, user_id)
+);
+
+CREATE INDEX poll_votes_option_id_idx ON poll_votes (option_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE poll_votes;
+
+DROP TABLE poll_options;
+
+DROP TABLE polls;
+
+-- +goose StatementEnd
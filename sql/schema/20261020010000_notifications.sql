@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    recipient_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    chirp_id INTEGER REFERENCES chirps (id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    actor_count INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP DEFAULT NULL
+);
+
+-- unread notifications of one type about one chirp coalesce into a single row
+CREATE UNIQUE INDEX notifications_unread_coalesce_idx ON notifications (recipient_id, type, chirp_id)
+WHERE read_at IS NULL;
+
+CREATE INDEX notifications_recipient_updated_at_idx ON notifications (recipient_id, updated_at, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notifications;
+
+-- +goose StatementEnd
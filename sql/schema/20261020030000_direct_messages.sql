@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN dm_policy TEXT NOT NULL DEFAULT 'everyone' CHECK (dm_policy IN ('everyone', 'nobody')),
+ADD COLUMN dm_filter BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- each pair of users has at most one conversation, stored with the smaller id first
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    user_a_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_b_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_a_id, user_b_id),
+    CHECK (user_a_id < user_b_id)
+);
+
+CREATE INDEX conversations_user_a_updated_at_idx ON conversations (user_a_id, updated_at, id);
+CREATE INDEX conversations_user_b_updated_at_idx ON conversations (user_b_id, updated_at, id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX messages_conversation_created_at_idx ON messages (conversation_id, created_at, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE messages;
+
+DROP TABLE conversations;
+
+ALTER TABLE users
+DROP COLUMN dm_filter,
+DROP COLUMN dm_policy;
+
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- each user's actor signs with its own key, created the first time it's needed
+CREATE TABLE ap_keys (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    private_key TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- remote actors following a local user
+CREATE TABLE ap_followers (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    actor_id TEXT NOT NULL,
+    inbox TEXT NOT NULL,
+    shared_inbox TEXT DEFAULT NULL,
+    follow_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, actor_id)
+);
+
+CREATE TABLE ap_deliveries (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    inbox TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER DEFAULT NULL,
+    last_error TEXT DEFAULT NULL,
+    delivered_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX ap_deliveries_due_idx ON ap_deliveries (next_attempt_at)
+WHERE status = 'pending';
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ap_deliveries;
+
+DROP TABLE ap_followers;
+
+DROP TABLE ap_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- previews are fetched once per URL and shared by every chirp linking to it
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'pending',
+    title TEXT DEFAULT NULL,
+    description TEXT DEFAULT NULL,
+    image_url TEXT DEFAULT NULL,
+    site_name TEXT DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT DEFAULT NULL,
+    fetched_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX link_previews_due_idx ON link_previews (next_attempt_at)
+WHERE status = 'pending';
+
+CREATE TABLE chirp_links (
+    chirp_id INTEGER NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL REFERENCES link_previews (url),
+    PRIMARY KEY (chirp_id, position),
+    UNIQUE (chirp_id, url)
+);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_links;
+
+DROP TABLE link_previews;
+
+-- +goose StatementEnd
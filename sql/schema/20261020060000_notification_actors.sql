@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the distinct actors behind each notification, so an actor repeating an
+-- action (rechirp, undo, rechirp) isn't counted again
+CREATE TABLE notification_actors (
+    notification_id UUID NOT NULL REFERENCES notifications (id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+CREATE INDEX notification_actors_actor_id_idx ON notification_actors (actor_id);
+
+INSERT INTO notification_actors (notification_id, actor_id)
+SELECT id, actor_id FROM notifications
+WHERE actor_id IS NOT NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_actors;
+
+-- +goose StatementEnd
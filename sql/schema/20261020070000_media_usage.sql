@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- upload limits add up a user's media
+CREATE INDEX media_user_id_created_at_idx ON media (user_id, created_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX media_user_id_created_at_idx;
+
+-- +goose StatementEnd
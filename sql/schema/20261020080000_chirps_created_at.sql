@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- feeds read the newest chirps, overall and by author
+CREATE INDEX chirps_created_at_idx ON chirps (created_at);
+
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_user_id_created_at_idx;
+
+DROP INDEX chirps_created_at_idx;
+
+-- +goose StatementEnd
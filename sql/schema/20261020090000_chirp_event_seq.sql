@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ids are taken when an event is recorded but only become visible when its
+-- transaction commits, so they aren't in commit order. seq is assigned after
+-- commit, one sequencer at a time, so streams can resume from it without
+-- missing events that committed late.
+CREATE SEQUENCE chirp_event_seq;
+
+ALTER TABLE chirp_events
+ADD COLUMN seq BIGINT UNIQUE DEFAULT NULL;
+
+UPDATE chirp_events SET seq = id;
+
+SELECT setval('chirp_event_seq', COALESCE((SELECT MAX(id) FROM chirp_events), 0) + 1, false);
+
+CREATE INDEX chirp_events_unsequenced_idx ON chirp_events (id)
+WHERE seq IS NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirp_events
+DROP COLUMN seq;
+
+DROP SEQUENCE chirp_event_seq;
+
+-- +goose StatementEnd
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+const (
+	PlanChirpyRed = entitlements.PlanRed
+
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPastDue   = "past_due"
+	SubscriptionStatusCancelled = "cancelled"
+	SubscriptionStatusExpired   = "expired"
+
+	subscriptionPeriod         = 30 * 24 * time.Hour
+	subscriptionExpiryInterval = time.Minute
+)
+
+// userLimits returns the entitlements of the user's current plan.
+func (cfg *apiConfig) userLimits(ctx context.Context, userID uuid.UUID) (entitlements.Limits, error) {
+	plan, err := cfg.db.GetUserPlan(ctx, userID)
+	if err != nil {
+		return entitlements.Limits{}, err
+	}
+	return cfg.plans.For(plan), nil
+}
+
+// activateSubscription marks the user as Chirpy Red and (re)starts their
+// subscription in a single transaction.
+func (cfg *apiConfig) activateSubscription(ctx context.Context, userID uuid.UUID, plan string, periodEnd time.Time) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.UpgradeUser(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := qtx.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		UserID:           userID,
+		Plan:             plan,
+		Status:           SubscriptionStatusActive,
+		CurrentPeriodEnd: sql.NullTime{Time: periodEnd, Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// endSubscription removes Chirpy Red immediately instead of waiting for the
+// current period to run out.
+func (cfg *apiConfig) endSubscription(ctx context.Context, userID uuid.UUID) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.DowngradeUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.EndSubscription(ctx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// runSubscriptionExpiry periodically expires subscriptions whose period has
+// ended and drops the Chirpy Red flag from their users.
+func (cfg *apiConfig) runSubscriptionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := cfg.db.ExpireSubscriptions(ctx)
+		if err != nil {
+			log.Printf("Error expiring subscriptions: %s", err)
+		} else if n > 0 {
+			log.Printf("Expired %d subscriptions", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
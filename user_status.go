@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userStatusTTL bounds how long a suspension or ban can take to reach access
+// tokens that were issued before it.
+const userStatusTTL = 30 * time.Second
+
+type userStatus struct {
+	banned         bool
+	suspendedUntil sql.NullTime
+}
+
+// restriction explains why the user may not use the API, or returns "" if
+// they may.
+func (s userStatus) restriction(now time.Time) string {
+	if s.banned {
+		return "Your account has been banned"
+	}
+	if s.suspendedUntil.Valid && s.suspendedUntil.Time.After(now) {
+		return fmt.Sprintf("Your account is suspended until %s", s.suspendedUntil.Time.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+type userStatusEntry struct {
+	status    userStatus
+	expiresAt time.Time
+}
+
+type userStatusCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uuid.UUID]userStatusEntry
+}
+
+func newUserStatusCache(ttl time.Duration) *userStatusCache {
+	return &userStatusCache{
+		ttl:     ttl,
+		entries: map[uuid.UUID]userStatusEntry{},
+	}
+}
+
+func (c *userStatusCache) get(userID uuid.UUID, now time.Time) (userStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok {
+		return userStatus{}, false
+	}
+	if now.After(entry.expiresAt) {
+		delete(c.entries, userID)
+		return userStatus{}, false
+	}
+	return entry.status, true
+}
+
+func (c *userStatusCache) set(userID uuid.UUID, status userStatus, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[userID] = userStatusEntry{status: status, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *userStatusCache) invalidate(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, userID)
+}
+
+// sweep drops expired entries so users who stop making requests don't stay
+// cached forever.
+func (c *userStatusCache) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for userID, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, userID)
+		}
+	}
+}
+
+// userStatus returns the user's ban and suspension state, using the cache
+// when it is fresh.
+func (cfg *apiConfig) userStatus(ctx context.Context, userID uuid.UUID) (userStatus, error) {
+	now := time.Now()
+	if status, ok := cfg.userStatuses.get(userID, now); ok {
+		return status, nil
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return userStatus{}, err
+	}
+
+	status := userStatus{
+		banned:         user.BannedAt.Valid,
+		suspendedUntil: user.SuspendedUntil,
+	}
+	cfg.userStatuses.set(userID, status, now)
+	return status, nil
+}
+
+func (cfg *apiConfig) runUserStatusSweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cfg.userStatuses.sweep(now)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/bontaramsonta/go-chirpy/internal/database"
+	"github.com/bontaramsonta/go-chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventChirpCreated = "chirp.created"
+	WebhookEventChirpDeleted = "chirp.deleted"
+	WebhookEventUserUpdated  = "user.updated"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+
+	webhookDeliveryInterval  = 5 * time.Second
+	webhookDeliveryTimeout   = 10 * time.Second
+	webhookDeliveryBatchSize = 20
+	// a claimed delivery is retried after the lease if the worker dies mid-send
+	webhookDeliveryLease       = 5 * time.Minute
+	webhookMaxAttempts         = 8
+	webhookMaxEndpointFailures = 10
+)
+
+var outboundWebhookEvents = map[string]struct{}{
+	WebhookEventChirpCreated: {},
+	WebhookEventChirpDeleted: {},
+	WebhookEventUserUpdated:  {},
+}
+
+// enqueueWebhookEvent queues a delivery of the event to every enabled endpoint
+// subscribed to it. Call it with the transaction that made the change so the
+// event is only queued if the change commits. A valid ownerID restricts
+// delivery to that user's own endpoints.
+func enqueueWebhookEvent(ctx context.Context, q *database.Queries, eventType string, ownerID uuid.NullUUID, data any) error {
+	payload, err := json.Marshal(struct {
+		ID        uuid.UUID `json:"id"`
+		Type      string    `json:"type"`
+		CreatedAt time.Time `json:"created_at"`
+		Data      any       `json:"data"`
+	}{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		EventType: eventType,
+		Payload:   payload,
+		UserID:    ownerID,
+	})
+	return err
+}
+
+// runWebhookDeliveries periodically sends due webhook deliveries. Deliveries
+// are claimed with row locks, so several instances can run this safely.
+func (cfg *apiConfig) runWebhookDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := cfg.db.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+			LeaseSeconds: int32(webhookDeliveryLease.Seconds()),
+			BatchSize:    webhookDeliveryBatchSize,
+		})
+		if err != nil {
+			log.Printf("Error claiming webhook deliveries: %s", err)
+		}
+		for _, delivery := range deliveries {
+			cfg.attemptWebhookDelivery(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) attemptWebhookDelivery(ctx context.Context, delivery database.WebhookDelivery) {
+	endpoint, err := cfg.db.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		log.Printf("Error loading webhook endpoint %s: %s", delivery.EndpointID, err)
+		return
+	}
+
+	statusCode, deliverErr := cfg.webhookClient.Deliver(ctx, endpoint.Url, endpoint.Secret, delivery.ID.String(), delivery.EventType, delivery.Payload)
+	lastStatusCode := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+
+	if deliverErr == nil {
+		if err := cfg.db.MarkWebhookDeliverySucceeded(ctx, database.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: lastStatusCode,
+		}); err != nil {
+			log.Printf("Error recording webhook delivery %s: %s", delivery.ID, err)
+		}
+		if err := cfg.db.ResetWebhookEndpointFailures(ctx, endpoint.ID); err != nil {
+			log.Printf("Error resetting webhook endpoint %s failures: %s", endpoint.ID, err)
+		}
+		return
+	}
+
+	// the delivery log only gets a summary, so keep the detail here
+	if statusCode == 0 {
+		log.Printf("Error delivering webhook %s: %s", delivery.ID, deliverErr)
+	}
+	status := WebhookDeliveryPending
+	if delivery.Attempts >= webhookMaxAttempts {
+		status = WebhookDeliveryFailed
+	}
+	if err := cfg.db.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+		ID:                delivery.ID,
+		Status:            status,
+		LastStatusCode:    lastStatusCode,
+		LastError:         sql.NullString{String: webhooks.DescribeError(statusCode, deliverErr), Valid: true},
+		RetryAfterSeconds: int32(webhooks.Backoff(int(delivery.Attempts)).Seconds()),
+	}); err != nil {
+		log.Printf("Error recording webhook delivery %s: %s", delivery.ID, err)
+	}
+
+	updated, err := cfg.db.RecordWebhookEndpointFailure(ctx, database.RecordWebhookEndpointFailureParams{
+		ID:          endpoint.ID,
+		MaxFailures: webhookMaxEndpointFailures,
+	})
+	if err != nil {
+		log.Printf("Error recording webhook endpoint %s failure: %s", endpoint.ID, err)
+		return
+	}
+	if endpoint.Enabled && !updated.Enabled {
+		log.Printf("Disabled webhook endpoint %s after %d consecutive failures", endpoint.ID, updated.FailureCount)
+	}
+}